@@ -0,0 +1,211 @@
+package catalog
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// strippedParams are query parameters left out of canonical patterns:
+// credentials, which must never end up in a report, and cache-busters and
+// cache controls, which vary between copies of the same badge.
+var strippedParams = map[string]struct{}{
+	// Credentials
+	"token":         {},
+	"access_token":  {},
+	"auth":          {},
+	"auth_token":    {},
+	"private_token": {},
+	"api_key":       {},
+	"apikey":        {},
+	"key":           {},
+	"jwt":           {},
+	"sig":           {},
+	"signature":     {},
+	"secret":        {},
+	"password":      {},
+	// Cache-busters
+	"_":           {},
+	"cb":          {},
+	"bust":        {},
+	"cachebust":   {},
+	"cachebuster": {},
+	"cache_bust":  {},
+	"cache-bust":  {},
+	"nocache":     {},
+	"no_cache":    {},
+	"t":           {},
+	"ts":          {},
+	"time":        {},
+	"timestamp":   {},
+	"rand":        {},
+	"random":      {},
+	"rnd":         {},
+	// Cache controls
+	"cacheseconds": {},
+	"maxage":       {},
+	"max-age":      {},
+}
+
+// strippedParamPrefixes are prefixes of signed URL parameters.
+var strippedParamPrefixes = []string{"x-amz-", "x-goog-"}
+
+// imageExtensions are the extensions a repository name may carry when it
+// names the badge image itself, as in travis-ci.org/{ORG}/{REPO}.svg.
+var imageExtensions = []string{".svg", ".png", ".gif", ".jpg", ".jpeg", ".webp", ".json"}
+
+// Canonicalize turns a badge image URL into a pattern shared by every
+// repository using the same badge. Path segments and query values naming the
+// organization or repository become {ORG} and {REPO}; hosts and partial
+// segments are never substituted. Query parameters are sorted, credentials
+// and cache-busters are removed along with any user information and
+// fragment, and the scheme and host are lowercased. Everything else is kept.
+func Canonicalize(rawURL, org, repo string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Opaque != "" {
+		return rawURL
+	}
+	s := substitution{org: org, repo: repo}
+
+	var b strings.Builder
+	if u.Scheme != "" {
+		b.WriteString(strings.ToLower(u.Scheme))
+		b.WriteString(":")
+	}
+	if u.Host != "" {
+		b.WriteString("//")
+		b.WriteString(strings.ToLower(u.Host))
+	}
+	b.WriteString(s.path(u.EscapedPath()))
+	if query := s.query(u.RawQuery); query != "" {
+		b.WriteString("?")
+		b.WriteString(query)
+	}
+	return b.String()
+}
+
+// substitution replaces whole organization and repository names.
+type substitution struct {
+	org, repo string
+}
+
+// path substitutes the segments of an escaped path. When the path names
+// the repository right after the organization, as most badge services do,
+// only that occurrence becomes {REPO}, so a repository named like a branch
+// or a path component elsewhere in the URL leaves it alone.
+func (s substitution) path(escaped string) string {
+	segments := strings.Split(escaped, "/")
+	pair := -1
+	for i := 1; i < len(segments); i++ {
+		if s.isOrg(segments[i-1]) {
+			if _, ok := s.repoSegment(segments[i]); ok {
+				pair = i
+				break
+			}
+		}
+	}
+
+	for i, segment := range segments {
+		switch {
+		case s.isOrg(segment) && i != pair:
+			segments[i] = "{ORG}"
+		case i == pair:
+			segments[i], _ = s.repoSegment(segment)
+		case pair < 0 && s.isRepo(segment):
+			segments[i] = "{REPO}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// query sorts the parameters of a raw query by name, drops stripped ones
+// and substitutes whole values, or whole slash-separated parts of values
+// such as owner/repo.
+func (s substitution) query(raw string) string {
+	type param struct{ name, text string }
+	var params []param
+	for _, part := range strings.Split(raw, "&") {
+		if part == "" {
+			continue
+		}
+		key, value, hasValue := strings.Cut(part, "=")
+		name := unescape(key, url.QueryUnescape)
+		if isStripped(name) {
+			continue
+		}
+		if hasValue {
+			part = key + "=" + s.value(value)
+		}
+		params = append(params, param{name: name, text: part})
+	}
+	// Repeated parameters keep their relative order, which may be significant
+	slices.SortStableFunc(params, func(a, b param) int {
+		return strings.Compare(a.name, b.name)
+	})
+
+	texts := make([]string, len(params))
+	for i, p := range params {
+		texts[i] = p.text
+	}
+	return strings.Join(texts, "&")
+}
+
+func (s substitution) value(raw string) string {
+	parts := strings.Split(raw, "/")
+	for i, part := range parts {
+		name := unescape(part, url.QueryUnescape)
+		switch {
+		case name == "":
+			// Empty parts name nothing
+		case strings.EqualFold(name, s.org) && (i == 0 || !strings.EqualFold(name, s.repo)):
+			parts[i] = "{ORG}"
+		case strings.EqualFold(name, s.repo):
+			parts[i] = "{REPO}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+func (s substitution) isOrg(segment string) bool {
+	return s.org != "" && strings.EqualFold(unescape(segment, url.PathUnescape), s.org)
+}
+
+func (s substitution) isRepo(segment string) bool {
+	return s.repo != "" && strings.EqualFold(unescape(segment, url.PathUnescape), s.repo)
+}
+
+// repoSegment substitutes a segment naming the repository, on its own or
+// with an image extension.
+func (s substitution) repoSegment(segment string) (string, bool) {
+	if s.isRepo(segment) {
+		return "{REPO}", true
+	}
+	for _, ext := range imageExtensions {
+		if len(segment) > len(ext) && strings.EqualFold(segment[len(segment)-len(ext):], ext) && s.isRepo(segment[:len(segment)-len(ext)]) {
+			return "{REPO}" + segment[len(segment)-len(ext):], true
+		}
+	}
+	return segment, false
+}
+
+// isStripped reports whether a query parameter is left out of patterns.
+func isStripped(name string) bool {
+	name = strings.ToLower(name)
+	if _, ok := strippedParams[name]; ok {
+		return true
+	}
+	for _, prefix := range strippedParamPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// unescape decodes a URL component, keeping it as is when it is malformed.
+func unescape(s string, decode func(string) (string, error)) string {
+	if decoded, err := decode(s); err == nil {
+		return decoded
+	}
+	return s
+}
@@ -0,0 +1,202 @@
+// Package catalog identifies badges: it canonicalizes badge image URLs into
+// organization-independent patterns and looks them up in the badges.json
+// catalog of known badges.
+package catalog
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Unknown is the name and category of badges missing from the catalog.
+const Unknown = "Unknown"
+
+// defaultsJSON is the built-in catalog of widely used badges, consulted
+// after the entries of badges.json.
+//
+//go:embed defaults.json
+var defaultsJSON []byte
+
+// DefaultPath is the catalog read when no catalog files are configured. It
+// is optional, unlike configured files.
+const DefaultPath = "badges.json"
+
+// Config represents the badges.json configuration.
+type Config struct {
+	Badges []Entry `json:"badges" yaml:"badges"`
+	// UseDefaults set to false leaves out the built-in catalog.
+	UseDefaults *bool `json:"defaults,omitempty" yaml:"defaults"`
+	// DisableDefaults lists the IDs of built-in entries to leave out.
+	DisableDefaults []string `json:"disable_defaults,omitempty" yaml:"disable_defaults"`
+
+	matcher atomic.Pointer[Matcher]
+}
+
+// Entry represents a single badge configuration entry.
+type Entry struct {
+	ID          string `json:"id" yaml:"id"`
+	Pattern     string `json:"pattern" yaml:"pattern"`
+	Name        string `json:"name" yaml:"name"`
+	Category    string `json:"category" yaml:"category"`
+	Placeholder string `json:"placeholder,omitempty" yaml:"placeholder"`
+	// Match selects how Pattern is interpreted: MatchPattern (the default),
+	// MatchRegex or MatchHost.
+	Match string `json:"match,omitempty" yaml:"match"`
+	// IgnoreQuery matches badges whatever their query parameters.
+	IgnoreQuery bool `json:"ignore_query,omitempty" yaml:"ignore_query"`
+	// Default marks entries from the built-in catalog.
+	Default bool `json:"-" yaml:"-"`
+	// Source is the catalog file the entry was read from.
+	Source string `json:"-" yaml:"-"`
+	// index is the entry's position in its catalog file.
+	index int
+}
+
+// Load reads and merges the catalog files at paths, then appends the
+// built-in defaults they do not disable or override. Without paths it reads
+// DefaultPath when it exists, so a fresh install relies on the defaults alone.
+func Load(paths ...string) (*Config, error) {
+	if len(paths) == 0 {
+		config, err := Read(DefaultPath)
+		if errors.Is(err, fs.ErrNotExist) {
+			return (&Config{}).WithDefaults(Defaults()), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return config.WithDefaults(Defaults()), nil
+	}
+
+	configs := make([]*Config, 0, len(paths))
+	for _, path := range paths {
+		config, err := Read(path)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+	return Merge(configs...).WithDefaults(Defaults()), nil
+}
+
+// Read reads a single catalog file, as YAML when its extension is .yaml or
+// .yml and as JSON otherwise. Malformed files are reported with the line and
+// column of the error.
+func Read(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read badge catalog: %w", err)
+	}
+
+	var config Config
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	default:
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, decodeError(path, data, err)
+		}
+	}
+	for i := range config.Badges {
+		config.Badges[i].Source = path
+		config.Badges[i].index = i
+	}
+	return &config, nil
+}
+
+// Merge layers catalogs so that later ones take precedence: their entries
+// are consulted first and replace entries with the same ID from earlier
+// catalogs. Disabled defaults accumulate, and the last catalog that sets
+// defaults decides whether they are used.
+func Merge(configs ...*Config) *Config {
+	merged := &Config{}
+	overridden := make(map[string]struct{})
+	for i := len(configs) - 1; i >= 0; i-- {
+		config := configs[i]
+		for _, entry := range config.Badges {
+			if _, ok := overridden[entry.ID]; ok && entry.ID != "" {
+				continue
+			}
+			merged.Badges = append(merged.Badges, entry)
+		}
+		for _, entry := range config.Badges {
+			if entry.ID != "" {
+				overridden[entry.ID] = struct{}{}
+			}
+		}
+		if merged.UseDefaults == nil {
+			merged.UseDefaults = config.UseDefaults
+		}
+	}
+	for _, config := range configs {
+		merged.DisableDefaults = append(merged.DisableDefaults, config.DisableDefaults...)
+	}
+	return merged
+}
+
+// Defaults returns the built-in catalog entries.
+func Defaults() []Entry {
+	var config Config
+	if err := json.Unmarshal(defaultsJSON, &config); err != nil {
+		panic(fmt.Sprintf("invalid built-in badge catalog: %v", err))
+	}
+	for i := range config.Badges {
+		config.Badges[i].Default = true
+	}
+	return config.Badges
+}
+
+// WithDefaults returns the catalog followed by the defaults, except those
+// disabled by ID and those whose ID a catalog entry reuses to override them.
+func (c *Config) WithDefaults(defaults []Entry) *Config {
+	merged := &Config{
+		Badges:          append([]Entry{}, c.Badges...),
+		UseDefaults:     c.UseDefaults,
+		DisableDefaults: c.DisableDefaults,
+	}
+	if c.UseDefaults != nil && !*c.UseDefaults {
+		return merged
+	}
+
+	skip := make(map[string]struct{})
+	for _, id := range c.DisableDefaults {
+		skip[id] = struct{}{}
+	}
+	for _, entry := range c.Badges {
+		if entry.ID != "" {
+			skip[entry.ID] = struct{}{}
+		}
+	}
+	for _, entry := range defaults {
+		if _, ok := skip[entry.ID]; !ok {
+			merged.Badges = append(merged.Badges, entry)
+		}
+	}
+	return merged
+}
+
+// Matcher returns the catalog compiled for lookups. It is compiled on first
+// use, so the catalog must not be changed afterwards.
+func (c *Config) Matcher() *Matcher {
+	if m := c.matcher.Load(); m != nil {
+		return m
+	}
+	c.matcher.CompareAndSwap(nil, Compile(c))
+	return c.matcher.Load()
+}
+
+// Lookup returns the catalog entry matching a canonical pattern. Unknown
+// badges get the PatternID of the pattern.
+func (c *Config) Lookup(pattern string) (name, category, placeholder, id string) {
+	return c.Matcher().Lookup(pattern)
+}
@@ -0,0 +1,238 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatches(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		catalogPattern string
+		pattern        string
+		want           bool
+	}{
+		{"https://img.shields.io/github/license/{ORG}/{REPO}/.*", "https://img.shields.io/github/license/{ORG}/{REPO}/.*", true},
+		{"https://img.shields.io/npm/v/.*", "https://img.shields.io/npm/v/left-pad", true},
+		{"https://img.shields.io/npm/v/.*", "https://img.shields.io/npm/dm/left-pad", false},
+		{"https://codecov.io/.*", "https://codecov.io/gh/{ORG}/{REPO}/.*", true},
+		{"https://github.com/{ORG}/{REPO}/.*", "https://github.com/{ORG}", false},
+		{"https://github.com/{ORG}/{REPO}/.*", "https://github.com/{ORG}/{REPO}", true},
+		{"https://travis-ci.org/{ORG}/{REPO}/.*", "https://travis-ci.org/{ORG}/{REPO}.svg?branch=master", true},
+		{"https://travis-ci.org/{ORG}/{REPO}/.*", "https://travis-ci.org/{ORG}/{REPO}/{ORG}", true},
+		{"https://codecov.io/.*", "https://codecov.io", false},
+		{"https://img.shields.io/badge/license-MIT-blue.svg", "https://img.shields.io/badge/license-MIT-blue.svg", true},
+		{"https://img.shields.io/badge/license-MIT-blue.svg", "https://img.shields.io/badge/license-MITXblue.svg", false},
+	}
+	for _, tt := range tests {
+		if got := Matches(tt.catalogPattern, tt.pattern); got != tt.want {
+			t.Errorf("Matches(%q, %q) = %v, want %v", tt.catalogPattern, tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	t.Parallel()
+
+	defaults := Defaults()
+	if len(defaults) == 0 {
+		t.Fatal("Defaults() is empty")
+	}
+	ids := make(map[string]struct{})
+	for _, entry := range defaults {
+		if entry.ID == "" || entry.Pattern == "" || entry.Name == "" || entry.Category == "" {
+			t.Errorf("default entry %+v is incomplete", entry)
+		}
+		if _, ok := ids[entry.ID]; ok {
+			t.Errorf("duplicate default ID %q", entry.ID)
+		}
+		ids[entry.ID] = struct{}{}
+		if _, err := compileEntry(entry); err != nil {
+			t.Errorf("default %q pattern does not compile: %v", entry.ID, err)
+		}
+		if !IsSafeID(entry.ID) {
+			t.Errorf("default ID %q is not URL- and filesystem-safe", entry.ID)
+		}
+		if !entry.Default {
+			t.Errorf("default %q is not marked as a default", entry.ID)
+		}
+	}
+
+	config := (&Config{}).WithDefaults(defaults)
+	tests := []struct {
+		pattern string
+		wantID  string
+	}{
+		{"https://img.shields.io/github/license/{ORG}/{REPO}/.*", "shields-github-license"},
+		{"https://github.com/{ORG}/{REPO}/actions/workflows/ci.yml/badge.svg?branch=main", "github-actions"},
+		{"https://github.com/{ORG}/{REPO}/workflows/CI/badge.svg", "github-actions"},
+		{"https://github.com/{ORG}/{REPO}/raw/main/logo.png", ""},
+		{"https://github.com/{ORG}/{REPO}/releases/download/v1/badge.svg", ""},
+		{"https://github.com/{ORG}/{REPO}/blob/main/docs/badge.svg", ""},
+		{"https://github.com/{ORG}/{REPO}/workflows/CI/badge.svg/extra", ""},
+		{"https://goreportcard.com/badge/github.com/{ORG}/{REPO}/.*", "go-report-card"},
+		{"https://img.shields.io/maven-central/v/com.example/library", "shields-maven-central"},
+	}
+	for _, tt := range tests {
+		name, _, _, id := config.Lookup(tt.pattern)
+		if tt.wantID == "" {
+			// Other images hosted on github.com are not workflow badges
+			if name != Unknown {
+				t.Errorf("Lookup(%q) = %q (%s), want unknown", tt.pattern, name, id)
+			}
+			continue
+		}
+		if id != tt.wantID {
+			t.Errorf("Lookup(%q) id = %q, want %q", tt.pattern, id, tt.wantID)
+		}
+	}
+}
+
+func TestWithDefaults(t *testing.T) {
+	t.Parallel()
+
+	defaults := []Entry{
+		{ID: "codecov", Pattern: "https://codecov.io/.*", Name: "Codecov", Category: "Coverage", Default: true},
+		{ID: "go-report-card", Pattern: "https://goreportcard.com/.*", Name: "Go Report Card", Category: "Quality", Default: true},
+		{ID: "travis-ci", Pattern: "https://travis-ci.org/.*", Name: "Travis CI", Category: "Build", Default: true},
+	}
+	disabled := false
+
+	tests := []struct {
+		name    string
+		config  *Config
+		wantIDs []string
+	}{
+		{"empty catalog uses every default", &Config{}, []string{"codecov", "go-report-card", "travis-ci"}},
+		{
+			"user entries come first",
+			&Config{Badges: []Entry{{ID: "license", Pattern: "https://img.shields.io/github/license/.*"}}},
+			[]string{"license", "codecov", "go-report-card", "travis-ci"},
+		},
+		{
+			"same ID overrides a default",
+			&Config{Badges: []Entry{{ID: "codecov", Pattern: "https://codecov.io/.*", Name: "Coverage", Category: "Quality"}}},
+			[]string{"codecov", "go-report-card", "travis-ci"},
+		},
+		{"disable by ID", &Config{DisableDefaults: []string{"travis-ci"}}, []string{"codecov", "go-report-card"}},
+		{"disable all", &Config{UseDefaults: &disabled}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			merged := tt.config.WithDefaults(defaults)
+			var ids []string
+			for _, entry := range merged.Badges {
+				ids = append(ids, entry.ID)
+			}
+			if len(ids) != len(tt.wantIDs) {
+				t.Fatalf("WithDefaults() ids = %v, want %v", ids, tt.wantIDs)
+			}
+			for i := range ids {
+				if ids[i] != tt.wantIDs[i] {
+					t.Fatalf("WithDefaults() ids = %v, want %v", ids, tt.wantIDs)
+				}
+			}
+		})
+	}
+
+	config := &Config{Badges: []Entry{{ID: "codecov", Pattern: "https://codecov.io/.*", Name: "Coverage", Category: "Quality"}}}
+	if name, category, _, _ := config.WithDefaults(defaults).Lookup("https://codecov.io/gh/{ORG}/{REPO}/.*"); name != "Coverage" || category != "Quality" {
+		t.Errorf("overridden default = %s (%s), want the user entry", name, category)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "shared.json")
+	team := filepath.Join(dir, "team.yaml")
+	writeFile(t, shared, `{
+  "disable_defaults": ["travis-ci-org"],
+  "badges": [
+    {"id": "mit", "pattern": "https://img.shields.io/badge/license-MIT-blue.svg", "name": "MIT", "category": "License"},
+    {"id": "status", "pattern": "https://img.shields.io/badge/status-.*", "name": "Status", "category": "Project Status"}
+  ]
+}`)
+	writeFile(t, team, `defaults: false
+badges:
+  - id: status
+    pattern: https://img.shields.io/badge/status-.*
+    name: Lifecycle
+    category: Project Status
+  - id: internal-ci
+    pattern: https://ci.example.com/{ORG}/{REPO}/.*
+    name: Internal CI
+    category: Build
+`)
+
+	config, err := Load(shared, team)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	var got []string
+	for _, entry := range config.Badges {
+		got = append(got, entry.Source+":"+entry.ID)
+	}
+	want := []string{team + ":status", team + ":internal-ci", shared + ":mit"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Load() entries = %v, want %v with the later file first and no defaults", got, want)
+	}
+	if name, _, _, _ := config.Lookup("https://img.shields.io/badge/status-wip-yellow"); name != "Lifecycle" {
+		t.Errorf("Lookup() name = %q, want the later file's entry", name)
+	}
+
+	config, err = Load(shared)
+	if err != nil {
+		t.Fatalf("Load(shared) error = %v", err)
+	}
+	if len(config.Badges) != 2+len(Defaults())-1 {
+		t.Errorf("Load(shared) has %d entries, want its own plus the defaults except travis-ci-org", len(config.Badges))
+	}
+
+	if _, err := Load(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("Load(missing) error = nil, want an error for a configured file that does not exist")
+	}
+
+	bad := filepath.Join(dir, "bad.yml")
+	writeFile(t, bad, "badges:\n  - id: [unclosed\n")
+	if _, err := Load(bad); err == nil || !strings.Contains(err.Error(), "line") {
+		t.Errorf("Load(bad YAML) error = %v, want the line of the error", err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+
+	enabled, disabled := true, false
+	merged := Merge(
+		&Config{UseDefaults: &disabled, DisableDefaults: []string{"codecov"}, Badges: []Entry{{ID: "a"}, {ID: "b"}}},
+		&Config{UseDefaults: &enabled, DisableDefaults: []string{"coveralls"}, Badges: []Entry{{ID: "b", Name: "B"}, {Pattern: "no-id"}}},
+		&Config{Badges: []Entry{{ID: "c"}}},
+	)
+
+	var ids []string
+	for _, entry := range merged.Badges {
+		ids = append(ids, entry.ID+entry.Name)
+	}
+	if got := strings.Join(ids, ","); got != "c,bB,,a" {
+		t.Errorf("Merge() ids = %s, want c,bB,,a", got)
+	}
+	if merged.UseDefaults == nil || !*merged.UseDefaults {
+		t.Errorf("Merge() UseDefaults = %v, want the last setting", merged.UseDefaults)
+	}
+	if got := strings.Join(merged.DisableDefaults, ","); got != "codecov,coveralls" {
+		t.Errorf("Merge() DisableDefaults = %s", got)
+	}
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,67 @@
+package catalog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// safeID matches IDs usable as is in URLs, file names and space-separated
+// attribute lists on any filesystem: lowercase letters and digits separated
+// by single dots, dashes or underscores.
+var safeID = regexp.MustCompile(`^[a-z0-9]+(?:[._-][a-z0-9]+)*$`)
+
+// unsafeRun finds the characters slugs replace with a dash.
+var unsafeRun = regexp.MustCompile(`[^a-z0-9]+`)
+
+// maxSlugLength bounds the readable part of derived IDs.
+const maxSlugLength = 48
+
+// IsSafeID reports whether an ID can be used as is in URLs and file names.
+func IsSafeID(id string) bool {
+	return safeID.MatchString(id)
+}
+
+// SafeID returns the ID when it is safe, and otherwise a slug of it followed
+// by a hash of the original, so distinct IDs stay distinct.
+func SafeID(id string) string {
+	if IsSafeID(id) {
+		return id
+	}
+	return slugWithHash(id, id)
+}
+
+// PatternID derives the ID of a badge from its canonical pattern: a readable
+// slug of the host and path followed by a hash of the whole pattern. It is
+// the same on every run and differs between patterns.
+func PatternID(pattern string) string {
+	readable := pattern
+	if _, rest, ok := strings.Cut(readable, "://"); ok {
+		readable = rest
+	}
+	return slugWithHash(readable, pattern)
+}
+
+// VariantID derives the ID of one of several patterns sharing an ID.
+func VariantID(id, pattern string) string {
+	return SafeID(id) + "-" + hashString(pattern)
+}
+
+func slugWithHash(readable, hashed string) string {
+	slug := unsafeRun.ReplaceAllString(strings.ToLower(readable), "-")
+	if len(slug) > maxSlugLength {
+		slug = slug[:maxSlugLength]
+	}
+	slug = strings.Trim(slug, "-")
+	if slug == "" {
+		return "badge-" + hashString(hashed)
+	}
+	return slug + "-" + hashString(hashed)
+}
+
+func hashString(s string) string {
+	h := sha256.New()
+	h.Write([]byte(s))
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
@@ -0,0 +1,72 @@
+package catalog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSafeID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		id       string
+		want     string
+		wantSafe bool
+	}{
+		{"github-actions", "github-actions", true},
+		{"codecov_v2.svg", "codecov_v2.svg", true},
+		{"MIT", "mit-", false},
+		{"Work In Progress", "work-in-progress-", false},
+		{"../../etc/passwd", "etc-passwd-", false},
+		{"a--b", "a-b-", false},
+		{"ünïcode", "n-code-", false},
+		{"???", "badge-", false},
+	}
+	for _, tt := range tests {
+		if got := IsSafeID(tt.id); got != tt.wantSafe {
+			t.Errorf("IsSafeID(%q) = %v, want %v", tt.id, got, tt.wantSafe)
+		}
+		got := SafeID(tt.id)
+		if tt.wantSafe && got != tt.want || !tt.wantSafe && !strings.HasPrefix(got, tt.want) {
+			t.Errorf("SafeID(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+		if !IsSafeID(got) {
+			t.Errorf("SafeID(%q) = %q is not safe", tt.id, got)
+		}
+	}
+	if SafeID("MIT") == SafeID("Mit") {
+		t.Error("SafeID() maps IDs differing in case to the same ID")
+	}
+}
+
+func TestPatternID(t *testing.T) {
+	t.Parallel()
+
+	patterns := []string{
+		"https://img.shields.io/github/license/{ORG}/{REPO}",
+		"https://img.shields.io/github/license/{ORG}/{REPO}?style=flat",
+		"https://img.shields.io/github/license/{ORG}/{REPO}?style=flat-square",
+		"https://example.com/" + strings.Repeat("very-long-segment/", 20) + "badge.svg",
+		"",
+	}
+	seen := make(map[string]string)
+	for _, pattern := range patterns {
+		id := PatternID(pattern)
+		if !IsSafeID(id) {
+			t.Errorf("PatternID(%q) = %q is not safe", pattern, id)
+		}
+		if id != PatternID(pattern) {
+			t.Errorf("PatternID(%q) is not deterministic", pattern)
+		}
+		if other, ok := seen[id]; ok {
+			t.Errorf("PatternID(%q) = PatternID(%q) = %q", pattern, other, id)
+		}
+		seen[id] = pattern
+	}
+	if got := PatternID(patterns[0]); !strings.HasPrefix(got, "img-shields-io-github-license-org-repo-") {
+		t.Errorf("PatternID(%q) = %q, want a readable prefix", patterns[0], got)
+	}
+	if got := VariantID("Actions", patterns[0]); !IsSafeID(got) || got == SafeID("Actions") {
+		t.Errorf("VariantID() = %q, want a safe ID distinct from the shared one", got)
+	}
+}
@@ -0,0 +1,139 @@
+package catalog
+
+import (
+	"slices"
+	"sync"
+)
+
+// Matcher is a catalog compiled for lookups. Entries are indexed by the host
+// their pattern requires, so a lookup only tries the entries that can match
+// its host plus those whose host is itself a placeholder or wildcard, in
+// catalog order.
+type Matcher struct {
+	entries []matcherEntry
+	// byHost lists, per host, the indexes of the entries that can match it.
+	byHost map[string][]int
+	// anyHost lists the indexes of the entries not tied to one host.
+	anyHost []int
+	// results caches the winning entry index, or -1, per pattern.
+	results sync.Map
+}
+
+type matcherEntry struct {
+	Entry
+	// pos is the entry's position in the catalog.
+	pos  int
+	rule *rule
+}
+
+// Compile builds a matcher for the catalog. Entries whose pattern does not
+// compile never match; Validate reports them.
+func Compile(c *Config) *Matcher {
+	m := &Matcher{byHost: make(map[string][]int)}
+	for pos, entry := range c.Badges {
+		r, err := compileEntry(entry)
+		if err != nil {
+			continue
+		}
+		i := len(m.entries)
+		m.entries = append(m.entries, matcherEntry{Entry: entry, pos: pos, rule: r})
+
+		if r.host != "" {
+			m.byHost[r.host] = append(m.byHost[r.host], i)
+		} else {
+			m.anyHost = append(m.anyHost, i)
+		}
+	}
+
+	// Every host also tries the host-independent entries, merged in catalog
+	// order to keep first-match semantics
+	for host, indexes := range m.byHost {
+		merged := slices.Concat(indexes, m.anyHost)
+		slices.Sort(merged)
+		m.byHost[host] = merged
+	}
+	return m
+}
+
+// candidates returns the indexes of the entries that can match the pattern,
+// in catalog order.
+func (m *Matcher) candidates(pattern string) []int {
+	if host, ok := patternHost(pattern); ok {
+		if indexes, ok := m.byHost[host]; ok {
+			return indexes
+		}
+	}
+	return m.anyHost
+}
+
+func (m *Matcher) matches(i int, pattern string) bool {
+	return m.entries[i].rule.match(pattern)
+}
+
+// Find returns the first catalog entry matching a canonical pattern.
+func (m *Matcher) Find(pattern string) (Entry, bool) {
+	if cached, ok := m.results.Load(pattern); ok {
+		if i := cached.(int); i >= 0 {
+			return m.entries[i].Entry, true
+		}
+		return Entry{}, false
+	}
+
+	winner := -1
+	for _, i := range m.candidates(pattern) {
+		if m.matches(i, pattern) {
+			winner = i
+			break
+		}
+	}
+	m.results.Store(pattern, winner)
+	if winner < 0 {
+		return Entry{}, false
+	}
+	return m.entries[winner].Entry, true
+}
+
+// FindAll returns every catalog entry matching a canonical pattern, in
+// catalog order; the first is the one Find returns.
+func (m *Matcher) FindAll(pattern string) []Entry {
+	var found []Entry
+	for _, i := range m.candidates(pattern) {
+		if m.matches(i, pattern) {
+			found = append(found, m.entries[i].Entry)
+		}
+	}
+	return found
+}
+
+// positions returns the catalog positions of every entry matching a
+// canonical pattern, for reporting entries by position.
+func (m *Matcher) positions(pattern string) []int {
+	var found []int
+	for _, i := range m.candidates(pattern) {
+		if m.matches(i, pattern) {
+			found = append(found, m.entries[i].pos)
+		}
+	}
+	return found
+}
+
+// Lookup returns the name, category, placeholder and ID of the catalog entry
+// matching a canonical pattern. Unknown badges, and entries without an ID,
+// get the PatternID of the pattern.
+func (m *Matcher) Lookup(pattern string) (name, category, placeholder, id string) {
+	entry, found := m.Find(pattern)
+	return Describe(pattern, entry, found)
+}
+
+// Describe returns what Lookup reports for a pattern from the entry Find
+// returned for it, for callers that also need the entry.
+func Describe(pattern string, entry Entry, found bool) (name, category, placeholder, id string) {
+	if !found {
+		return Unknown, Unknown, "", PatternID(pattern)
+	}
+	id = entry.ID
+	if id == "" {
+		id = PatternID(pattern)
+	}
+	return entry.Name, entry.Category, entry.Placeholder, id
+}
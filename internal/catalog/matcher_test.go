@@ -0,0 +1,144 @@
+package catalog
+
+import (
+	"fmt"
+	"testing"
+)
+
+// linearLookup is the reference first-match semantics: every entry in
+// catalog order.
+func linearLookup(c *Config, pattern string) string {
+	for _, entry := range c.Badges {
+		if r, err := compileEntry(entry); err == nil && r.match(pattern) {
+			return entry.ID
+		}
+	}
+	return ""
+}
+
+func TestMatcherFirstMatch(t *testing.T) {
+	t.Parallel()
+
+	config := (&Config{Badges: []Entry{
+		{ID: "any-license", Pattern: ".*/license/.*"},
+		{ID: "shields-status", Pattern: "https://img.shields.io/badge/status-.*"},
+		{ID: "any-scheme", Pattern: "{ORG}://example.com/x"},
+		{ID: "exact", Pattern: "https://example.com"},
+		{ID: "example-path", Pattern: "https://example.com/{ORG}/{REPO}/.*"},
+		{ID: "pages", Pattern: "https://{ORG}.github.io/.*"},
+		{ID: "query", Pattern: "https://example.com?badge=.*"},
+		{ID: "actions", Pattern: "https://github.com/{OWNER}/{REPO}/actions/workflows/{WORKFLOW}/badge.svg", IgnoreQuery: true},
+		{ID: "regex", Pattern: `https://(www\.)?example\.net/b/[0-9]+`, Match: MatchRegex},
+		{ID: "docs", Pattern: "*.readthedocs.io", Match: MatchHost},
+		{ID: "host", Pattern: "Example.org", Match: MatchHost},
+		{ID: "bad-regex", Pattern: "(", Match: MatchRegex},
+	}}).WithDefaults(Defaults())
+	m := Compile(config)
+
+	patterns := []string{
+		"https://img.shields.io/github/license/{ORG}/{REPO}/.*",
+		"https://img.shields.io/badge/status-wip-yellow",
+		"https://img.shields.io/npm/v/left-pad",
+		"https://example.com",
+		"https://example.com/",
+		"https://example.com/{ORG}/{REPO}/.*",
+		"https://example.com?badge=build",
+		"http://example.com/x",
+		"https://org.github.io/project/badge.svg",
+		"https://codecov.io/gh/{ORG}/{REPO}/.*",
+		"https://github.com/{ORG}/{REPO}/.*",
+		"https://unknown.example.org/badge.svg",
+		"https://github.com/{ORG}/{REPO}/actions/workflows/ci.yml/badge.svg?branch=main",
+		"https://example.net/b/42",
+		"https://www.example.net/b/42",
+		"https://project.readthedocs.io/en/latest/?badge=latest",
+		"https://readthedocs.io/x",
+		"https://EXAMPLE.org:8443/x",
+		"not a url",
+		"",
+	}
+	for _, pattern := range patterns {
+		want := linearLookup(config, pattern)
+		entry, ok := m.Find(pattern)
+		if entry.ID != want || ok != (want != "") {
+			t.Errorf("Find(%q) = %q, %v, want %q", pattern, entry.ID, ok, want)
+		}
+		// A second lookup is served from the cache
+		if again, _ := m.Find(pattern); again.ID != want {
+			t.Errorf("cached Find(%q) = %q, want %q", pattern, again.ID, want)
+		}
+	}
+}
+
+func TestMatcherFindAll(t *testing.T) {
+	t.Parallel()
+
+	config := &Config{Badges: []Entry{
+		{ID: "codecov-gh", Pattern: "https://codecov.io/gh/.*"},
+		{ID: "broken", Pattern: ""},
+		{ID: "any-repo", Pattern: ".*/{REPO}/.*"},
+		{ID: "codecov", Pattern: "https://codecov.io/.*"},
+	}}
+	var ids []string
+	for _, entry := range config.Matcher().FindAll("https://codecov.io/gh/{ORG}/{REPO}/.*") {
+		ids = append(ids, entry.ID)
+	}
+	if got := fmt.Sprint(ids); got != "[codecov-gh any-repo codecov]" {
+		t.Errorf("FindAll() = %s, want every match in catalog order", got)
+	}
+	if got := config.Matcher().positions("https://codecov.io/gh/{ORG}/{REPO}/.*"); fmt.Sprint(got) != "[0 2 3]" {
+		t.Errorf("positions() = %v, want catalog positions", got)
+	}
+}
+
+// benchmarkCatalog builds a catalog of 500 entries spread over 50 hosts,
+// and the badge patterns of 1,000 repositories with five badges each.
+func benchmarkCatalog() (*Config, []string) {
+	config := &Config{}
+	for i := range 500 {
+		config.Badges = append(config.Badges, Entry{
+			ID:      fmt.Sprintf("badge-%d", i),
+			Pattern: fmt.Sprintf("https://badges%d.example.com/kind%d/{ORG}/{REPO}/.*", i%50, i/50),
+			Name:    fmt.Sprintf("Badge %d", i),
+		})
+	}
+	var patterns []string
+	for repo := range 1000 {
+		for badge := range 5 {
+			n := (repo*5 + badge) % 600
+			patterns = append(patterns, fmt.Sprintf("https://badges%d.example.com/kind%d/{ORG}/{REPO}/.*", n%50, n/50))
+		}
+	}
+	return config, patterns
+}
+
+func BenchmarkMatcherLookup(b *testing.B) {
+	config, patterns := benchmarkCatalog()
+	b.ResetTimer()
+	for b.Loop() {
+		m := Compile(config)
+		for _, pattern := range patterns {
+			m.Lookup(pattern)
+		}
+	}
+}
+
+// BenchmarkLinearLookup scans every precompiled entry in order, the way
+// lookups worked before the matcher, without recompiling patterns.
+func BenchmarkLinearLookup(b *testing.B) {
+	config, patterns := benchmarkCatalog()
+	compiled := make([]*rule, len(config.Badges))
+	for i, entry := range config.Badges {
+		compiled[i], _ = compileEntry(entry)
+	}
+	b.ResetTimer()
+	for b.Loop() {
+		for _, pattern := range patterns {
+			for _, r := range compiled {
+				if r.match(pattern) {
+					break
+				}
+			}
+		}
+	}
+}
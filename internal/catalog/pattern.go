@@ -0,0 +1,187 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Match modes of a catalog entry.
+const (
+	// MatchPattern matches the whole canonical pattern against a URL with
+	// placeholders and .* wildcards.
+	MatchPattern = "pattern"
+	// MatchRegex matches the whole canonical pattern against a Go regular
+	// expression.
+	MatchRegex = "regex"
+	// MatchHost matches every badge served from a host, or with a leading
+	// "*." from any of its subdomains.
+	MatchHost = "host"
+)
+
+// placeholderPatterns are the regular expressions catalog pattern
+// placeholders stand for. Owners, organizations, repositories, branches and
+// workflow files are a single path segment or query value; packages may span
+// segments, as in @scope/name or com.example/library.
+var placeholderPatterns = map[string]string{
+	"{ORG}":      `[^/?#]+`,
+	"{OWNER}":    `[^/?#]+`,
+	"{REPO}":     `[^/?#]+`,
+	"{BRANCH}":   `[^/?#&]+`,
+	"{WORKFLOW}": `[^/?#&]+`,
+	"{PACKAGE}":  `[^?#]+?`,
+	".*":         `.*`,
+}
+
+// placeholderToken finds placeholders in a quoted pattern.
+var placeholderToken = regexp.MustCompile(`\\\{(ORG|OWNER|REPO|BRANCH|WORKFLOW|PACKAGE)\\\}|\\\.\\\*`)
+
+// placeholderTail finds a trailing /.* after a placeholder, which patterns
+// written for URLs truncated after the repository name end with.
+var placeholderTail = regexp.MustCompile(`\}/\.\*$`)
+
+// rule is a catalog entry compiled for matching.
+type rule struct {
+	re *regexp.Regexp
+	// prefix is the literal text every matching pattern starts with.
+	prefix string
+	// host is the lowercase host every matching pattern has, or empty when
+	// the entry is not tied to a single host.
+	host        string
+	ignoreQuery bool
+}
+
+// compileEntry compiles an entry according to its match mode.
+func compileEntry(entry Entry) (*rule, error) {
+	mode := entry.Match
+	if mode == "" {
+		mode = MatchPattern
+	}
+	pattern := entry.Pattern
+	if entry.IgnoreQuery && mode != MatchHost {
+		pattern = stripQuery(pattern)
+	}
+	r, err := compileRule(mode, pattern)
+	if err != nil {
+		return nil, err
+	}
+	r.ignoreQuery = entry.IgnoreQuery
+	return r, nil
+}
+
+func compileRule(mode, pattern string) (*rule, error) {
+	var expr string
+	switch mode {
+	case MatchPattern:
+		// A trailing /.* after a placeholder also matches a pattern ending
+		// there or continuing with a query, as in {REPO}.svg?branch=main
+		tail := ""
+		if placeholderTail.MatchString(pattern) {
+			pattern, tail = strings.TrimSuffix(pattern, "/.*"), `(?:[/?#].*)?`
+		}
+		expr = regexp.QuoteMeta(pattern)
+		expr = placeholderToken.ReplaceAllStringFunc(expr, func(token string) string {
+			return placeholderPatterns[strings.ReplaceAll(token, `\`, "")]
+		}) + tail
+	case MatchRegex:
+		expr = "(?:" + pattern + ")"
+	case MatchHost:
+		host, err := hostExpr(pattern)
+		if err != nil {
+			return nil, err
+		}
+		expr = `[A-Za-z][A-Za-z0-9+.-]*://(?:[^/?#@]*@)?` + host + `(?::[0-9]+)?(?:[/?#].*)?`
+	default:
+		return nil, fmt.Errorf("unknown match mode %q (expected %s, %s or %s)", mode, MatchPattern, MatchRegex, MatchHost)
+	}
+
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return nil, err
+	}
+	prefix, complete := re.LiteralPrefix()
+	r := &rule{re: re, prefix: prefix}
+	if mode == MatchHost && !strings.HasPrefix(pattern, "*.") {
+		r.host = strings.ToLower(pattern)
+	} else if host, ok := prefixHost(prefix, complete); ok {
+		r.host = host
+	}
+	return r, nil
+}
+
+// hostExpr converts a host, optionally with a leading "*." for any
+// subdomain, to a case-insensitive regular expression.
+func hostExpr(host string) (string, error) {
+	if host == "" || strings.ContainsAny(host, "/?#@: ") {
+		return "", errors.New("host patterns must be a bare host name such as codecov.io or *.readthedocs.io")
+	}
+	if rest, ok := strings.CutPrefix(host, "*."); ok {
+		return `(?i:(?:[^./?#@:]+\.)+` + regexp.QuoteMeta(rest) + `)`, nil
+	}
+	return `(?i:` + regexp.QuoteMeta(host) + `)`, nil
+}
+
+// match reports whether a canonical pattern matches the rule.
+func (r *rule) match(pattern string) bool {
+	if r.ignoreQuery {
+		pattern = stripQuery(pattern)
+	}
+	return strings.HasPrefix(pattern, r.prefix) && r.re.MatchString(pattern)
+}
+
+// stripQuery removes the query string, keeping any fragment.
+func stripQuery(pattern string) string {
+	i := strings.IndexByte(pattern, '?')
+	if i < 0 {
+		return pattern
+	}
+	if j := strings.IndexByte(pattern[i:], '#'); j >= 0 {
+		return pattern[:i] + pattern[i+j:]
+	}
+	return pattern[:i]
+}
+
+// Matches reports whether a canonical pattern matches a catalog pattern in
+// the default pattern mode, in which placeholders such as {ORG} and {REPO}
+// match a single path segment and .* matches anything. The catalog pattern
+// is compiled on every call; lookups against a whole catalog use a Matcher.
+func Matches(catalogPattern, pattern string) bool {
+	r, err := compileEntry(Entry{Pattern: catalogPattern})
+	if err != nil {
+		return false
+	}
+	return r.match(pattern)
+}
+
+// prefixHost returns the lowercase host of a literal pattern prefix,
+// provided the prefix includes the whole host: either the character ending
+// it or, for prefixes that are the whole pattern, the end of the pattern.
+// Ports and user information are left out.
+func prefixHost(prefix string, complete bool) (string, bool) {
+	_, rest, ok := strings.Cut(prefix, "://")
+	if !ok {
+		return "", false
+	}
+	end := strings.IndexAny(rest, "/?#")
+	if end < 0 {
+		if !complete {
+			return "", false
+		}
+		end = len(rest)
+	}
+	host := rest[:end]
+	if i := strings.LastIndexByte(host, '@'); i >= 0 {
+		host = host[i+1:]
+	}
+	if i := strings.LastIndexByte(host, ':'); i >= 0 {
+		host = host[:i]
+	}
+	return strings.ToLower(host), true
+}
+
+// patternHost returns the host of a canonical pattern, as prefixHost finds
+// it in catalog patterns.
+func patternHost(pattern string) (string, bool) {
+	return prefixHost(pattern, true)
+}
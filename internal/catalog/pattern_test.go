@@ -0,0 +1,94 @@
+package catalog
+
+import "testing"
+
+func TestCompileEntry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		entry   Entry
+		pattern string
+		want    bool
+	}{
+		{"org and repo", Entry{Pattern: "https://img.shields.io/github/license/{ORG}/{REPO}"}, "https://img.shields.io/github/license/{ORG}/{REPO}", true},
+		{"owner is any owner", Entry{Pattern: "https://img.shields.io/github/license/{OWNER}/{REPO}"}, "https://img.shields.io/github/license/kubernetes/kubernetes", true},
+		{"repo is one segment", Entry{Pattern: "https://example.com/{REPO}"}, "https://example.com/a/b", false},
+		{"workflow file", Entry{Pattern: "https://github.com/{OWNER}/{REPO}/actions/workflows/{WORKFLOW}/badge.svg"}, "https://github.com/{ORG}/{REPO}/actions/workflows/release.yaml/badge.svg", true},
+		{"branch in query", Entry{Pattern: "https://github.com/{OWNER}/{REPO}/actions/workflows/{WORKFLOW}/badge.svg?branch={BRANCH}"}, "https://github.com/{ORG}/{REPO}/actions/workflows/ci.yml/badge.svg?branch=main", true},
+		{"branch is one query value", Entry{Pattern: "https://example.com/badge.svg?branch={BRANCH}"}, "https://example.com/badge.svg?branch=main&event=push", false},
+		{"branch in path", Entry{Pattern: "https://codecov.io/gh/{ORG}/{REPO}/branch/{BRANCH}/graph/badge.svg"}, "https://codecov.io/gh/{ORG}/{REPO}/branch/main/graph/badge.svg", true},
+		{"scoped package", Entry{Pattern: "https://img.shields.io/npm/v/{PACKAGE}"}, "https://img.shields.io/npm/v/@scope/name", true},
+		{"package stops at query", Entry{Pattern: "https://img.shields.io/npm/v/{PACKAGE}"}, "https://img.shields.io/npm/v/name?style=flat", false},
+		{"maven package", Entry{Pattern: "https://img.shields.io/maven-central/v/{PACKAGE}.svg"}, "https://img.shields.io/maven-central/v/com.example/library.svg", true},
+		{"query required by default", Entry{Pattern: "https://github.com/{OWNER}/{REPO}/actions/workflows/{WORKFLOW}/badge.svg"}, "https://github.com/{ORG}/{REPO}/actions/workflows/ci.yml/badge.svg?branch=main", false},
+		{"ignore query", Entry{Pattern: "https://github.com/{OWNER}/{REPO}/actions/workflows/{WORKFLOW}/badge.svg", IgnoreQuery: true}, "https://github.com/{ORG}/{REPO}/actions/workflows/ci.yml/badge.svg?branch=main&event=push", true},
+		{"ignore query in entry too", Entry{Pattern: "https://example.com/badge.svg?style=flat", IgnoreQuery: true}, "https://example.com/badge.svg?style=for-the-badge", true},
+		{"ignore query keeps path", Entry{Pattern: "https://example.com/badge.svg", IgnoreQuery: true}, "https://example.com/other.svg?x=1", false},
+		{"regex", Entry{Pattern: `https://img\.shields\.io/(npm|pypi)/v/.+`, Match: MatchRegex}, "https://img.shields.io/pypi/v/requests", true},
+		{"regex is anchored", Entry{Pattern: `shields\.io`, Match: MatchRegex}, "https://img.shields.io/pypi/v/requests", false},
+		{"regex alternation is anchored", Entry{Pattern: `a|https://b`, Match: MatchRegex}, "https://b/x", false},
+		{"host", Entry{Pattern: "codecov.io", Match: MatchHost}, "https://codecov.io/gh/{ORG}/{REPO}/.*", true},
+		{"host ignores case and port", Entry{Pattern: "codecov.io", Match: MatchHost}, "http://Codecov.IO:8080", true},
+		{"host excludes subdomains", Entry{Pattern: "codecov.io", Match: MatchHost}, "https://app.codecov.io/x", false},
+		{"host excludes lookalikes", Entry{Pattern: "codecov.io", Match: MatchHost}, "https://codecov.io.example.com/x", false},
+		{"host wildcard", Entry{Pattern: "*.readthedocs.io", Match: MatchHost}, "https://a.b.readthedocs.io/en/latest?badge=latest", true},
+		{"host wildcard needs subdomain", Entry{Pattern: "*.readthedocs.io", Match: MatchHost}, "https://readthedocs.io/", false},
+		{"host in path", Entry{Pattern: "codecov.io", Match: MatchHost}, "https://example.com/codecov.io", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r, err := compileEntry(tt.entry)
+			if err != nil {
+				t.Fatalf("compileEntry() error = %v", err)
+			}
+			if got := r.match(tt.pattern); got != tt.want {
+				t.Errorf("match(%q) = %v, want %v (regex %s)", tt.pattern, got, tt.want, r.re)
+			}
+		})
+	}
+}
+
+func TestCompileEntryErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, entry := range []Entry{
+		{Pattern: "(", Match: MatchRegex},
+		{Pattern: "https://codecov.io", Match: MatchHost},
+		{Pattern: "codecov.io/gh", Match: MatchHost},
+		{Pattern: "", Match: MatchHost},
+		{Pattern: "https://example.com", Match: "glob"},
+	} {
+		if _, err := compileEntry(entry); err == nil {
+			t.Errorf("compileEntry(%+v) error = nil", entry)
+		}
+	}
+}
+
+func TestCompileEntryHost(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		entry Entry
+		host  string
+	}{
+		{Entry{Pattern: "https://img.shields.io/github/license/{OWNER}/{REPO}"}, "img.shields.io"},
+		{Entry{Pattern: "https://Example.com:8443/x/.*"}, "example.com"},
+		{Entry{Pattern: "https://example.com"}, "example.com"},
+		{Entry{Pattern: "https://{ORG}.github.io/.*"}, ""},
+		{Entry{Pattern: `https://codecov\.io/.*`, Match: MatchRegex}, "codecov.io"},
+		{Entry{Pattern: `https://(www\.)?example\.net/.*`, Match: MatchRegex}, ""},
+		{Entry{Pattern: "Codecov.io", Match: MatchHost}, "codecov.io"},
+		{Entry{Pattern: "*.readthedocs.io", Match: MatchHost}, ""},
+	}
+	for _, tt := range tests {
+		r, err := compileEntry(tt.entry)
+		if err != nil {
+			t.Fatalf("compileEntry(%+v) error = %v", tt.entry, err)
+		}
+		if r.host != tt.host {
+			t.Errorf("compileEntry(%+v) host = %q, want %q", tt.entry, r.host, tt.host)
+		}
+	}
+}
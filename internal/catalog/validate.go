@@ -0,0 +1,279 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Problem severities. Errors make a catalog unusable as written; warnings
+// point at entries that probably do not do what was intended.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Problem is an issue found in a catalog.
+type Problem struct {
+	Severity string
+	// Entry describes the offending entry, or is empty for catalog-wide
+	// problems.
+	Entry   string
+	Message string
+}
+
+// String formats the problem for reports.
+func (p Problem) String() string {
+	if p.Entry == "" {
+		return fmt.Sprintf("%s: %s", p.Severity, p.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", p.Severity, p.Entry, p.Message)
+}
+
+// Errors counts the problems with error severity.
+func Errors(problems []Problem) int {
+	count := 0
+	for _, p := range problems {
+		if p.Severity == SeverityError {
+			count++
+		}
+	}
+	return count
+}
+
+// decodeError adds the line and column to JSON syntax and type errors.
+func decodeError(path string, data []byte, err error) error {
+	var offset int64 = -1
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	}
+	if offset < 0 {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	// Offsets count the offending byte as read
+	line, column := position(data, max(offset-1, 0))
+	return fmt.Errorf("failed to parse %s:%d:%d: %w", path, line, column, err)
+}
+
+// position converts a byte offset to a 1-based line and column.
+func position(data []byte, offset int64) (line, column int) {
+	offset = min(offset, int64(len(data)))
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	column = int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+// Validate checks the catalog entries from badges.json; built-in defaults
+// are assumed valid. With the canonical patterns of a crawl it also reports
+// entries that identify none of its badges.
+func Validate(c *Config, patterns []string) []Problem {
+	var problems []Problem
+	add := func(severity string, i int, format string, args ...any) {
+		problems = append(problems, Problem{
+			Severity: severity,
+			Entry:    describe(i, c.Badges[i]),
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	compiled := make([]*rule, len(c.Badges))
+	// Entries already reported as unusable are not reported as unused
+	skipUsage := make(map[int]bool)
+	ids := make(map[string]int)
+	for i, entry := range c.Badges {
+		if entry.Default {
+			continue
+		}
+
+		if entry.Pattern == "" {
+			add(SeverityError, i, "pattern is empty")
+			skipUsage[i] = true
+		} else if r, err := compileEntry(entry); err != nil {
+			add(SeverityError, i, "pattern does not compile: %v", err)
+			skipUsage[i] = true
+		} else {
+			compiled[i] = r
+		}
+
+		// IDs name badge pages, and some filesystems ignore case
+		if entry.ID != "" {
+			if first, ok := ids[strings.ToLower(entry.ID)]; ok {
+				add(SeverityError, i, "duplicate id, also used by %s", describe(first, c.Badges[first]))
+			} else {
+				ids[strings.ToLower(entry.ID)] = i
+			}
+			if !IsSafeID(entry.ID) {
+				add(SeverityWarning, i, "id is not URL- and filesystem-safe (use lowercase letters, digits, dots, dashes and underscores); its badge page is named %s", SafeID(entry.ID))
+			}
+		}
+		if entry.Name == "" {
+			add(SeverityWarning, i, "name is empty")
+		}
+		if entry.Category == "" {
+			add(SeverityWarning, i, "category is empty")
+		}
+		if entry.Placeholder != "" {
+			if err := validatePlaceholder(entry.Placeholder); err != nil {
+				add(SeverityError, i, "invalid placeholder %q: %v", entry.Placeholder, err)
+			}
+		}
+
+		// An earlier entry whose pattern matches this one's matches every
+		// badge this one would. Regular expressions and hosts do not read as
+		// badge patterns, so only plain patterns are compared.
+		for j := range i {
+			if compiled[j] != nil && compiled[i] != nil && isPatternMode(entry) && compiled[j].match(entry.Pattern) {
+				add(SeverityWarning, i, "shadowed by %s, which matches every badge this entry matches", describe(j, c.Badges[j]))
+				skipUsage[i] = true
+				break
+			}
+		}
+	}
+
+	if len(c.DisableDefaults) > 0 {
+		known := make(map[string]struct{})
+		for _, entry := range Defaults() {
+			known[entry.ID] = struct{}{}
+		}
+		for _, id := range c.DisableDefaults {
+			if _, ok := known[id]; !ok {
+				problems = append(problems, Problem{
+					Severity: SeverityWarning,
+					Message:  fmt.Sprintf("disable_defaults: no built-in badge has id %q", id),
+				})
+			}
+		}
+	}
+
+	if patterns != nil {
+		problems = append(problems, validateUsage(c, patterns, skipUsage)...)
+	}
+	return problems
+}
+
+// validateUsage reports badges.json entries that identify no crawled badge,
+// either because nothing matches them or because an earlier entry claims
+// every badge they match, and badges matched by several badges.json entries.
+func validateUsage(c *Config, patterns []string, skip map[int]bool) []Problem {
+	m := c.Matcher()
+	first := make(map[int]int)
+	claimedBy := make(map[int]int)
+	ambiguous := make(map[string][]string)
+	var ambiguousEntries [][]int
+	for _, pattern := range slices.Sorted(slices.Values(patterns)) {
+		matches := m.positions(pattern)
+		if len(matches) == 0 {
+			continue
+		}
+		first[matches[0]]++
+		for _, i := range matches[1:] {
+			if _, ok := claimedBy[i]; !ok {
+				claimedBy[i] = matches[0]
+			}
+		}
+
+		// Defaults are meant to be overridden, and shadowed entries are
+		// already reported
+		var own []int
+		for _, i := range matches {
+			if !c.Badges[i].Default && !skip[i] {
+				own = append(own, i)
+			}
+		}
+		if len(own) > 1 {
+			key := fmt.Sprint(own)
+			if _, ok := ambiguous[key]; !ok {
+				ambiguousEntries = append(ambiguousEntries, own)
+			}
+			ambiguous[key] = append(ambiguous[key], pattern)
+		}
+	}
+
+	var problems []Problem
+	for i, entry := range c.Badges {
+		if entry.Default || first[i] > 0 || skip[i] {
+			continue
+		}
+		message := "matches no badge in the crawl"
+		if winner, ok := claimedBy[i]; ok {
+			message = fmt.Sprintf("identifies no badge in the crawl; every badge it matches is matched first by %s", describe(winner, c.Badges[winner]))
+		}
+		problems = append(problems, Problem{Severity: SeverityWarning, Entry: describe(i, entry), Message: message})
+	}
+	// Entries that never match first are reported as unused above
+	for _, entries := range ambiguousEntries {
+		var others []string
+		for _, i := range entries[1:] {
+			if first[i] > 0 {
+				others = append(others, describe(i, c.Badges[i]))
+			}
+		}
+		if len(others) == 0 {
+			continue
+		}
+		matched := ambiguous[fmt.Sprint(entries)]
+		message := fmt.Sprintf("the crawled badge pattern %s also matches %s; this entry is used", matched[0], strings.Join(others, ", "))
+		if len(matched) > 1 {
+			message = fmt.Sprintf("%d crawled badge patterns, such as %s, also match %s; this entry is used", len(matched), matched[0], strings.Join(others, ", "))
+		}
+		problems = append(problems, Problem{
+			Severity: SeverityWarning,
+			Entry:    describe(entries[0], c.Badges[entries[0]]),
+			Message:  message,
+		})
+	}
+	return problems
+}
+
+func isPatternMode(entry Entry) bool {
+	return entry.Match == "" || entry.Match == MatchPattern
+}
+
+// validatePlaceholder requires an absolute http(s) URL or a site-relative
+// path.
+func validatePlaceholder(placeholder string) error {
+	u, err := url.Parse(placeholder)
+	if err != nil {
+		return err
+	}
+	switch u.Scheme {
+	case "http", "https":
+		if u.Host == "" {
+			return errors.New("missing host")
+		}
+	case "":
+		if u.Host != "" || u.Path == "" || u.Path[0] != '/' {
+			return errors.New("expected an absolute http(s) URL or a path starting with /")
+		}
+	default:
+		return fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	return nil
+}
+
+// describe names an entry by its file and position in it and, when set, ID.
+// Entries built in code rather than read from a file use their position in
+// the catalog.
+func describe(i int, entry Entry) string {
+	if entry.Default {
+		return fmt.Sprintf("built-in badge %q", entry.ID)
+	}
+	name := fmt.Sprintf("badges[%d]", i)
+	if entry.Source != "" {
+		name = fmt.Sprintf("%s: badges[%d]", entry.Source, entry.index)
+	}
+	if entry.ID == "" {
+		return name
+	}
+	return fmt.Sprintf("%s (id %q)", name, entry.ID)
+}
@@ -0,0 +1,141 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadReportsPosition(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"syntax error", "{\n  \"badges\": [\n    {\"id\": \"a\"}\n    {\"id\": \"b\"}\n  ]\n}\n", "badges.json:4:5:"},
+		{"type error", "{\n  \"badges\": [\n    {\"id\": 7}\n  ]\n}\n", "badges.json:3:12:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), "badges.json")
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := Load(path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want position %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	config := (&Config{
+		DisableDefaults: []string{"no-such-default"},
+		Badges: []Entry{
+			{ID: "shields", Pattern: "https://img.shields.io/.*", Name: "Shields", Category: "Other"},
+			{ID: "mit", Pattern: "https://img.shields.io/badge/license-MIT-blue.svg", Name: "MIT", Category: "License"},
+			{ID: "license", Pattern: "https://example.com/license.svg", Name: "License", Category: "License", Placeholder: "ftp://example.com/x.svg"},
+			{ID: "license", Pattern: "https://example.com/{ORG}/{REPO}/.*", Category: "License", Placeholder: "/badges/license.svg"},
+			{ID: "unused", Pattern: "https://unused.example.com/.*", Name: "Unused", Category: "Other"},
+			{ID: "empty", Name: "Empty", Category: "Other"},
+			{ID: "Unused", Pattern: "https://unused.example.org/.*", Name: "Unused", Category: "Other"},
+		},
+	}).WithDefaults(Defaults())
+
+	patterns := []string{
+		"https://img.shields.io/badge/license-MIT-blue.svg",
+		"https://example.com/license.svg",
+		"https://example.com/{ORG}/{REPO}/.*",
+		"https://codecov.io/gh/{ORG}/{REPO}/.*",
+	}
+	var got []string
+	for _, p := range Validate(config, patterns) {
+		got = append(got, p.String())
+	}
+
+	want := []string{
+		`warning: badges[1] (id "mit"): shadowed by badges[0] (id "shields")`,
+		`error: badges[2] (id "license"): invalid placeholder "ftp://example.com/x.svg"`,
+		`error: badges[3] (id "license"): duplicate id, also used by badges[2] (id "license")`,
+		`warning: badges[3] (id "license"): name is empty`,
+		`error: badges[5] (id "empty"): pattern is empty`,
+		`error: badges[6] (id "Unused"): duplicate id, also used by badges[4] (id "unused")`,
+		`warning: badges[6] (id "Unused"): id is not URL- and filesystem-safe`,
+		`warning: disable_defaults: no built-in badge has id "no-such-default"`,
+		`warning: badges[4] (id "unused"): matches no badge in the crawl`,
+		`warning: badges[6] (id "Unused"): matches no badge in the crawl`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Validate() = %d problems, want %d:\n%s", len(got), len(want), strings.Join(got, "\n"))
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("problem %d = %s, want prefix %s", i, got[i], want[i])
+		}
+	}
+	if n := Errors(Validate(config, nil)); n != 4 {
+		t.Errorf("Errors() = %d, want 4", n)
+	}
+}
+
+func TestValidateUsageClaimedByEarlierEntry(t *testing.T) {
+	t.Parallel()
+
+	config := &Config{Badges: []Entry{
+		{ID: "coverage", Pattern: "https://codecov.io/gh/{ORG}/.*", Name: "Coverage", Category: "Coverage"},
+		{ID: "codecov", Pattern: "https://codecov.io/.*/{REPO}/.*", Name: "Codecov", Category: "Coverage"},
+	}}
+	problems := Validate(config, []string{"https://codecov.io/gh/{ORG}/{REPO}/.*"})
+	if len(problems) != 1 || !strings.Contains(problems[0].Message, `matched first by badges[0] (id "coverage")`) {
+		t.Errorf("Validate() = %v, want codecov reported as claimed by coverage", problems)
+	}
+}
+
+func TestValidateAmbiguous(t *testing.T) {
+	t.Parallel()
+
+	config := (&Config{Badges: []Entry{
+		{ID: "coverage", Pattern: "https://codecov.io/gh/{ORG}/.*", Name: "Coverage", Category: "Coverage"},
+		{ID: "codecov", Pattern: "https://codecov.io/.*", Name: "Codecov", Category: "Coverage"},
+	}}).WithDefaults(Defaults())
+	problems := Validate(config, []string{
+		"https://codecov.io/gh/{ORG}/{REPO}/.*",
+		"https://codecov.io/gh/{ORG}/other/.*",
+		"https://codecov.io/github/{ORG}/{REPO}/.*",
+	})
+	if len(problems) != 1 {
+		t.Fatalf("Validate() = %v, want one ambiguity", problems)
+	}
+	want := `warning: badges[0] (id "coverage"): 2 crawled badge patterns, such as https://codecov.io/gh/{ORG}/other/.*, also match badges[1] (id "codecov"); this entry is used`
+	if got := problems[0].String(); got != want {
+		t.Errorf("Validate() = %s, want %s", got, want)
+	}
+}
+
+func TestValidatePlaceholder(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		placeholder string
+		wantErr     bool
+	}{
+		{"https://img.shields.io/badge/build-passing-green", false},
+		{"/static/badge.svg", false},
+		{"badge.svg", true},
+		{"https://", true},
+		{"javascript:alert(1)", true},
+		{"http://%zz", true},
+	}
+	for _, tt := range tests {
+		if err := validatePlaceholder(tt.placeholder); (err != nil) != tt.wantErr {
+			t.Errorf("validatePlaceholder(%q) error = %v, wantErr %v", tt.placeholder, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Package check classifies the badges of a local README and evaluates the
+// badge policy against it, so a CI job can block changes that break policy
+// before they merge.
+package check
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/catalog"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/crawler"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/policy"
+)
+
+// Options configures a check. Organization and Repository are used to
+// canonicalize badge URLs as in a crawl; Private, Language and Topics stand
+// in for the repository metadata policy scopes match on.
+type Options struct {
+	ReadmePath   string
+	Organization string
+	Repository   string
+	Private      bool
+	Language     string
+	Topics       []string
+	BadgeDomains map[string]struct{}
+	Catalog      *catalog.Config
+	// Policy is optional; without it badges are only classified.
+	Policy *policy.Policy
+}
+
+// Result is the outcome of checking a README.
+type Result struct {
+	Repository models.RepositoryData
+	Badges     []policy.Badge
+	Policy     policy.Result
+}
+
+// Violations counts the missing and forbidden badges.
+func (r *Result) Violations() int {
+	count := 0
+	for _, rule := range r.Policy.Rules {
+		count += len(rule.Missing) + len(rule.Forbidden)
+	}
+	return count
+}
+
+// Run reads the README and evaluates it like a crawled repository.
+func Run(opts Options) (*Result, error) {
+	content, err := os.ReadFile(opts.ReadmePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", opts.ReadmePath, err)
+	}
+
+	readmePath := filepath.ToSlash(opts.ReadmePath)
+	repo := models.RepositoryData{
+		SchemaVersion: models.SchemaVersion,
+		Repository:    opts.Repository,
+		Private:       opts.Private,
+		Language:      opts.Language,
+		Topics:        opts.Topics,
+		ReadmeFound:   true,
+		ReadmePath:    readmePath,
+		Badges:        crawler.ExtractFileBadges(readmePath, content, opts.BadgeDomains),
+	}
+
+	result := &Result{
+		Repository: repo,
+		Badges:     policy.Identify(repo, opts.Organization, opts.Catalog),
+	}
+	if opts.Policy != nil {
+		result.Policy = opts.Policy.Evaluate(repo, result.Badges)
+	}
+	return result, nil
+}
+
+// Print writes the classified badges and policy findings.
+func Print(w io.Writer, r *Result) {
+	repo := r.Repository
+	fmt.Fprintf(w, "Checking %s for %s\n", repo.ReadmePath, repo.Repository)
+
+	fmt.Fprintf(w, "\nFound %d badges:\n", len(r.Badges))
+	for _, b := range r.Badges {
+		badge := repo.Badges[b.Index]
+		fmt.Fprintf(w, "  %s:%d: %s: %s (%s)\n", badge.Source, badge.Line, b.Category, b.Name, badge.ImageURL)
+	}
+
+	if len(r.Policy.Rules) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nPolicy:")
+	for _, rule := range r.Policy.Rules {
+		status := "PASS"
+		if !rule.Passed() {
+			status = "FAIL"
+		}
+		fmt.Fprintf(w, "  %s %s\n", status, rule.Rule.Name)
+		for _, s := range rule.Missing {
+			fmt.Fprintf(w, "    %s: missing badge with %s\n", repo.ReadmePath, s)
+		}
+		for _, v := range rule.Forbidden {
+			badge := repo.Badges[v.Badge.Index]
+			name := v.Badge.Name
+			if name == catalog.Unknown {
+				name = badge.ImageURL
+			}
+			fmt.Fprintf(w, "    %s:%d: forbidden badge %s (matches %s)\n", badge.Source, badge.Line, name, v.Selector)
+		}
+	}
+
+	if violations := r.Violations(); violations > 0 {
+		fmt.Fprintf(w, "\n%d policy violations found.\n", violations)
+	} else {
+		fmt.Fprintln(w, "\nNo policy violations found.")
+	}
+}
@@ -0,0 +1,86 @@
+package check
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/catalog"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/policy"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	readme := filepath.Join(t.TempDir(), "README.md")
+	content := `# tool
+
+[![License](https://img.shields.io/github/license/example/tool)](LICENSE)
+[![Build](https://travis-ci.org/example/tool.svg)](https://travis-ci.org/example/tool)
+`
+	if err := os.WriteFile(readme, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := Options{
+		ReadmePath:   readme,
+		Organization: "example",
+		Repository:   "tool",
+		Language:     "Go",
+		BadgeDomains: map[string]struct{}{"img.shields.io": {}, "travis-ci.org": {}},
+		Catalog: &catalog.Config{Badges: []catalog.Entry{
+			{ID: "license", Pattern: "https://img.shields.io/github/license/{ORG}/{REPO}/.*", Name: "License", Category: "License"},
+		}},
+		Policy: &policy.Policy{Rules: []policy.Rule{{
+			Name:    "go-standards",
+			Scope:   policy.Scope{Languages: []string{"Go"}},
+			Require: []policy.Selector{{Category: "License"}, {Name: "Go Report Card"}},
+			Forbid:  []policy.Selector{{Pattern: "https://travis-ci.org/{ORG}/{REPO}/.*"}},
+		}}},
+	}
+
+	result, err := Run(opts)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(result.Badges) != 2 || result.Badges[0].Name != "License" {
+		t.Fatalf("Run() badges = %+v, want the license and Travis badges", result.Badges)
+	}
+	if got := result.Violations(); got != 2 {
+		t.Errorf("Violations() = %d, want 2", got)
+	}
+
+	var out bytes.Buffer
+	Print(&out, result)
+	for _, want := range []string{
+		"FAIL go-standards",
+		`missing badge with name "Go Report Card"`,
+		":4: forbidden badge https://travis-ci.org/example/tool.svg",
+		"2 policy violations found.",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Print() output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	// Without a policy, badges are classified and nothing fails.
+	opts.Policy = nil
+	result, err = Run(opts)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Violations() != 0 {
+		t.Errorf("Violations() without a policy = %d, want 0", result.Violations())
+	}
+}
+
+func TestRunMissingReadme(t *testing.T) {
+	t.Parallel()
+
+	_, err := Run(Options{ReadmePath: filepath.Join(t.TempDir(), "README.md"), Catalog: &catalog.Config{}})
+	if err == nil {
+		t.Fatal("Run() of a missing README succeeded")
+	}
+}
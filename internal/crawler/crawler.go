@@ -2,14 +2,12 @@ package crawler
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
-	"strings"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/dataset"
 	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
 	"github.com/google/go-github/v57/github"
 	"golang.org/x/oauth2"
@@ -17,28 +15,68 @@ import (
 
 const DefaultWorkerCount = 10
 
+// Fetch strategies for README content.
+const (
+	StrategyREST    = "rest"
+	StrategyGraphQL = "graphql"
+)
+
+// Options configures a crawl.
+type Options struct {
+	OrgName        string
+	OutputDir      string
+	Token          string
+	IncludePrivate bool
+	BadgeDomains   map[string]struct{}
+	// Strategy selects how READMEs are fetched: StrategyREST issues one call
+	// per repository, StrategyGraphQL batches many repositories per query and
+	// falls back to REST where no guessed README path exists.
+	Strategy string
+	// Refs selects the branch or tag to index for each repository.
+	Refs RefSelector
+	// ExtraPaths lists additional Markdown files or path.Match globs, relative
+	// to the repository root, whose badges are indexed alongside the README.
+	ExtraPaths []string
+	// BundlePath, when set, writes the crawl as a single bundle file instead
+	// of a directory of per-repository files in OutputDir.
+	BundlePath string
+	// SQLitePath, when set, also records the crawl as a run in a SQLite store
+	// that keeps every previous run.
+	SQLitePath string
+	// SnapshotDir, when set, also archives the crawl as a timestamped bundle
+	// in this directory for trend reporting.
+	SnapshotDir string
+}
+
 // Run executes the crawl phase.
-func Run(orgName, outputDir, token string, includePrivate bool, badgeDomains map[string]struct{}) error {
+func Run(opts Options) error {
+	if opts.Strategy == "" {
+		opts.Strategy = StrategyREST
+	}
+	if opts.Strategy != StrategyREST && opts.Strategy != StrategyGraphQL {
+		return fmt.Errorf("unknown fetch strategy %q", opts.Strategy)
+	}
+	if err := validatePathPatterns(opts.ExtraPaths); err != nil {
+		return err
+	}
+
 	ctx := context.Background()
 	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: token},
+		&oauth2.Token{AccessToken: opts.Token},
 	)
 	tc := oauth2.NewClient(ctx, ts)
+	usage := &apiUsage{}
+	tc.Transport = &countingTransport{base: tc.Transport, usage: usage}
 	client := github.NewClient(tc)
 
-	// Ensure output directory exists
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
-	}
-
 	// 1. List all repositories
-	fmt.Printf("Fetching repositories for org: %s...\n", orgName)
+	fmt.Printf("Fetching repositories for org: %s...\n", opts.OrgName)
 	var allRepos []*github.Repository
 	opt := &github.RepositoryListByOrgOptions{
 		ListOptions: github.ListOptions{PerPage: 100},
 	}
 	for {
-		repos, resp, err := client.Repositories.ListByOrg(ctx, orgName, opt)
+		repos, resp, err := client.Repositories.ListByOrg(ctx, opts.OrgName, opt)
 		if err != nil {
 			return fmt.Errorf("failed to list repositories: %w", err)
 		}
@@ -50,8 +88,8 @@ func Run(orgName, outputDir, token string, includePrivate bool, badgeDomains map
 	}
 	fmt.Printf("Found %d repositories.\n", len(allRepos))
 
-	// Filter out private repos unless includePrivate is set
-	if !includePrivate {
+	// Filter out private repos unless IncludePrivate is set
+	if !opts.IncludePrivate {
 		var publicRepos []*github.Repository
 		for _, repo := range allRepos {
 			if !repo.GetPrivate() {
@@ -72,9 +110,25 @@ func Run(orgName, outputDir, token string, includePrivate bool, badgeDomains map
 	fmt.Printf("Filtered to %d non-archived repositories.\n", len(activeRepos))
 	allRepos = activeRepos
 
-	// 2. Worker Pool for fetching READMEs
+	// 2. Prefetch READMEs in batches when using GraphQL
+	var prefetched map[string]readmeFile
+	if opts.Strategy == StrategyGraphQL {
+		fmt.Printf("Fetching READMEs via GraphQL in batches of up to %d files...\n", graphQLBlobsPerQuery)
+		var err error
+		prefetched, err = fetchReadmesGraphQL(ctx, client, allRepos, opts.Refs, usage)
+		if err != nil {
+			return fmt.Errorf("failed to fetch readmes: %w", err)
+		}
+		fmt.Printf("GraphQL found %d of %d READMEs; falling back to REST for the rest.\n", len(prefetched), len(allRepos))
+	}
+
+	// 3. Worker Pool for fetching READMEs
+	type result struct {
+		data models.RepositoryData
+		err  error
+	}
 	jobs := make(chan *github.Repository, len(allRepos))
-	results := make(chan error, len(allRepos))
+	results := make(chan result, len(allRepos))
 	var wg sync.WaitGroup
 
 	// Concurrency limit
@@ -82,7 +136,12 @@ func Run(orgName, outputDir, token string, includePrivate bool, badgeDomains map
 	for range workerCount {
 		wg.Go(func() {
 			for repo := range jobs {
-				results <- processRepo(ctx, client, repo, outputDir, badgeDomains)
+				var readme *readmeFile
+				if r, ok := prefetched[repo.GetName()]; ok {
+					readme = &r
+				}
+				data, err := processRepo(ctx, client, repo, readme, opts)
+				results <- result{data: data, err: err}
 			}
 		})
 	}
@@ -95,77 +154,161 @@ func Run(orgName, outputDir, token string, includePrivate bool, badgeDomains map
 	wg.Wait()
 	close(results)
 
+	bundle := &models.Bundle{
+		Organization: opts.OrgName,
+		Config:       opts.crawlConfig(),
+	}
+
 	// Check for errors
 	errCount := 0
-	for err := range results {
-		if err != nil {
-			fmt.Printf("Error processing repo: %v\n", err)
+	for r := range results {
+		if r.err != nil {
+			fmt.Printf("Error processing repo: %v\n", r.err)
 			errCount++
+			continue
 		}
+		bundle.Repositories = append(bundle.Repositories, r.data)
 	}
+	sort.Slice(bundle.Repositories, func(i, j int) bool {
+		return bundle.Repositories[i].Repository < bundle.Repositories[j].Repository
+	})
+
+	// 4. Organization profile README
+	profile, err := processOrgProfile(ctx, client, opts)
+	if err != nil {
+		fmt.Printf("Error processing organization profile: %v\n", err)
+		errCount++
+	}
+	bundle.Profile = profile
 
 	fmt.Printf("Crawl complete. Errors: %d\n", errCount)
+	fmt.Printf("API usage (%s strategy): %s\n", opts.Strategy, usage)
 
-	// Write timestamp.json
-	timestampData := map[string]string{
-		"last_crawled": time.Now().Format(time.RFC3339Nano),
+	// 5. Write output
+	bundle.CrawledAt = time.Now()
+	if opts.SQLitePath != "" {
+		runID, err := dataset.WriteSQLite(opts.SQLitePath, bundle)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Recorded crawl run %d in %s\n", runID, opts.SQLitePath)
 	}
-	tsFile, err := os.Create(filepath.Join(outputDir, "timestamp.json"))
-	if err != nil {
-		return fmt.Errorf("failed to create timestamp.json: %w", err)
+	if opts.SnapshotDir != "" {
+		path, err := dataset.WriteSnapshot(opts.SnapshotDir, bundle)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Archived snapshot: %s\n", path)
 	}
-	defer tsFile.Close()
+	if opts.BundlePath != "" {
+		fmt.Printf("Writing bundle: %s\n", opts.BundlePath)
+		return dataset.WriteBundle(opts.BundlePath, bundle)
+	}
+	return dataset.WriteDirectory(opts.OutputDir, bundle)
+}
 
-	tsEncoder := json.NewEncoder(tsFile)
-	tsEncoder.SetIndent("", "  ")
-	if err := tsEncoder.Encode(timestampData); err != nil {
-		return fmt.Errorf("failed to encode timestamp.json: %w", err)
+// crawlConfig records the options in the crawl output.
+func (o Options) crawlConfig() models.CrawlConfig {
+	domains := make([]string, 0, len(o.BadgeDomains))
+	for domain := range o.BadgeDomains {
+		domains = append(domains, domain)
 	}
+	sort.Strings(domains)
 
-	return nil
+	return models.CrawlConfig{
+		IncludePrivate: o.IncludePrivate,
+		Strategy:       o.Strategy,
+		Refs:           o.Refs.Default,
+		RefOverrides:   o.Refs.Overrides,
+		ExtraPaths:     o.ExtraPaths,
+		BadgeDomains:   domains,
+	}
 }
 
-func processRepo(ctx context.Context, client *github.Client, repo *github.Repository, outputDir string, badgeDomains map[string]struct{}) error {
+// processRepo extracts badges for a repository. When readme is nil the README
+// is fetched through the REST API.
+func processRepo(ctx context.Context, client *github.Client, repo *github.Repository, readme *readmeFile, opts Options) (models.RepositoryData, error) {
 	repoName := repo.GetName()
 	defaultBranch := repo.GetDefaultBranch()
+	refs := opts.Refs.candidates(repoName, defaultBranch)
 
-	// Try to fetch README
-	readme, _, err := client.Repositories.GetReadme(ctx, repo.GetOwner().GetLogin(), repoName, nil)
+	if readme == nil {
+		var err error
+		readme, err = fetchReadmeREST(ctx, client, repo, refs)
+		if err != nil {
+			return models.RepositoryData{}, err
+		}
+	}
 
 	readmeFound := false
 	var badges []models.Badge
+	// Without a README the last candidate, the default branch, was searched last
+	indexedRef := defaultBranch
+	detector := badgeDetector{domains: opts.BadgeDomains}
 
-	if err == nil && readme != nil {
+	readmePath := ""
+	if readme != nil {
 		readmeFound = true
-		var contentStr string
-		contentStr, err = readme.GetContent()
+		indexedRef = readme.Ref
+		readmePath = readme.Path
+		badges = extractFileBadges(*readme, detector)
+	}
+
+	if len(opts.ExtraPaths) > 0 && indexedRef != "" {
+		files, err := fetchExtraFiles(ctx, client, repo, indexedRef, opts.ExtraPaths, readmePath)
 		if err != nil {
-			return fmt.Errorf("failed to decode readme for %s: %w", repoName, err)
+			return models.RepositoryData{}, err
+		}
+		for _, f := range files {
+			badges = append(badges, extractFileBadges(f, detector)...)
 		}
-		badges = extractBadges([]byte(contentStr), badgeDetector{domains: badgeDomains})
 	}
 
-	data := models.RepositoryData{
+	return models.RepositoryData{
 		Repository:    repoName,
 		RepositoryURL: repo.GetHTMLURL(),
 		DefaultBranch: defaultBranch,
+		Ref:           indexedRef,
+		Private:       repo.GetPrivate(),
+		Language:      repo.GetLanguage(),
+		Topics:        repo.Topics,
 		ReadmeFound:   readmeFound,
+		ReadmePath:    readmePath,
 		Badges:        badges,
-	}
+	}, nil
+}
 
-	// Save to JSON
-	filename := filepath.Join(outputDir, fmt.Sprintf("%s.json", normalizeRepoName(repoName)))
-	file, err := os.Create(filename)
-	if err != nil {
-		return fmt.Errorf("failed to create file %s: %w", filename, err)
-	}
-	defer file.Close()
+// ExtractFileBadges detects the badges in a file the same way a crawl does,
+// tagging each with path, so local files can be checked without the GitHub API.
+func ExtractFileBadges(path string, content []byte, badgeDomains map[string]struct{}) []models.Badge {
+	return extractFileBadges(readmeFile{Path: path, Content: string(content)}, badgeDetector{domains: badgeDomains})
+}
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(data)
+// extractFileBadges extracts badges from a fetched file and tags each with the
+// file's path.
+func extractFileBadges(file readmeFile, detector badgeDetector) []models.Badge {
+	badges := extractBadges([]byte(file.Content), detector)
+	for i := range badges {
+		badges[i].Source = file.Path
+	}
+	return badges
 }
 
-func normalizeRepoName(name string) string {
-	return strings.ReplaceAll(strings.ToLower(name), "/", "-")
+// fetchReadmeREST fetches the README GitHub selects for the repository from
+// the first candidate ref that has one. A missing README is reported as nil
+// without an error.
+func fetchReadmeREST(ctx context.Context, client *github.Client, repo *github.Repository, refs []string) (*readmeFile, error) {
+	for _, ref := range refs {
+		readme, _, err := client.Repositories.GetReadme(ctx, repo.GetOwner().GetLogin(), repo.GetName(), &github.RepositoryContentGetOptions{Ref: ref})
+		if err != nil || readme == nil {
+			continue
+		}
+
+		content, err := readme.GetContent()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode readme for %s: %w", repo.GetName(), err)
+		}
+		return &readmeFile{Ref: ref, Path: readme.GetPath(), Content: content}, nil
+	}
+	return nil, nil
 }
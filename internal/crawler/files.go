@@ -0,0 +1,127 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// ParsePathList splits a comma separated list of extra file paths or globs.
+func ParsePathList(raw string) []string {
+	var paths []string
+	for p := range strings.SplitSeq(raw, ",") {
+		if p = strings.Trim(strings.TrimSpace(p), "/"); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// fetchExtraFiles fetches the files matching the given paths or globs at ref.
+// Literal paths are read through the contents API; globs are resolved against
+// the recursive git tree so that a single listing serves every pattern. Files
+// in skip (typically the root README) and missing paths are ignored.
+func fetchExtraFiles(ctx context.Context, client *github.Client, repo *github.Repository, ref string, patterns []string, skip string) ([]readmeFile, error) {
+	owner := repo.GetOwner().GetLogin()
+	name := repo.GetName()
+
+	var literals, globs []string
+	for _, p := range patterns {
+		if isGlob(p) {
+			globs = append(globs, p)
+		} else {
+			literals = append(literals, p)
+		}
+	}
+
+	seen := map[string]struct{}{skip: {}}
+	var files []readmeFile
+
+	for _, p := range literals {
+		if _, ok := seen[p]; ok {
+			continue
+		}
+		seen[p] = struct{}{}
+
+		content, _, resp, err := client.Repositories.GetContents(ctx, owner, name, p, &github.RepositoryContentGetOptions{Ref: ref})
+		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				continue
+			}
+			return nil, fmt.Errorf("failed to fetch %s from %s: %w", p, name, err)
+		}
+		if content == nil {
+			// Path is a directory
+			continue
+		}
+		text, err := content.GetContent()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s from %s: %w", p, name, err)
+		}
+		files = append(files, readmeFile{Ref: ref, Path: p, Content: text})
+	}
+
+	if len(globs) == 0 {
+		return files, nil
+	}
+
+	tree, resp, err := client.Git.GetTree(ctx, owner, name, ref, true)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return files, nil
+		}
+		return nil, fmt.Errorf("failed to list tree for %s: %w", name, err)
+	}
+	if tree.GetTruncated() {
+		fmt.Printf("Warning: tree for %s is truncated; some files may not be matched.\n", name)
+	}
+
+	var matches []*github.TreeEntry
+	for _, entry := range tree.Entries {
+		if entry.GetType() != "blob" {
+			continue
+		}
+		if _, ok := seen[entry.GetPath()]; ok {
+			continue
+		}
+		for _, g := range globs {
+			if ok, _ := path.Match(g, entry.GetPath()); ok {
+				seen[entry.GetPath()] = struct{}{}
+				matches = append(matches, entry)
+				break
+			}
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].GetPath() < matches[j].GetPath()
+	})
+
+	for _, entry := range matches {
+		data, _, err := client.Git.GetBlobRaw(ctx, owner, name, entry.GetSHA())
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s from %s: %w", entry.GetPath(), name, err)
+		}
+		files = append(files, readmeFile{Ref: ref, Path: entry.GetPath(), Content: string(data)})
+	}
+
+	return files, nil
+}
+
+// validatePathPatterns reports globs that path.Match cannot parse.
+func validatePathPatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid path pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
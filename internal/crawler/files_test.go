@@ -0,0 +1,67 @@
+package crawler
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+)
+
+func TestFetchExtraFiles(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/mono/contents/docs/index.md", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("ref"); got != "develop" {
+			t.Errorf("contents ref = %q, want develop", got)
+		}
+		fmt.Fprintf(w, `{"type": "file", "encoding": "base64", "content": %q}`, base64.StdEncoding.EncodeToString([]byte("docs")))
+	})
+	mux.HandleFunc("/repos/org/mono/contents/missing.md", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/repos/org/mono/git/trees/develop", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"truncated": false, "tree": [
+			{"path": "README.md", "type": "blob", "sha": "root"},
+			{"path": "packages/b/README.md", "type": "blob", "sha": "b"},
+			{"path": "packages/a/README.md", "type": "blob", "sha": "a"},
+			{"path": "packages/a/src/README.md", "type": "blob", "sha": "nested"},
+			{"path": "packages/c", "type": "tree", "sha": "c"}
+		]}`)
+	})
+	mux.HandleFunc("/repos/org/mono/git/blobs/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "blob %s", r.URL.Path[len("/repos/org/mono/git/blobs/"):])
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	repo := &github.Repository{Name: github.String("mono"), Owner: &github.User{Login: github.String("org")}}
+	patterns := []string{"docs/index.md", "missing.md", "packages/*/README.md", "*.md"}
+
+	files, err := fetchExtraFiles(context.Background(), client, repo, "develop", patterns, "README.md")
+	if err != nil {
+		t.Fatalf("fetchExtraFiles() error = %v", err)
+	}
+
+	want := []readmeFile{
+		{Ref: "develop", Path: "docs/index.md", Content: "docs"},
+		{Ref: "develop", Path: "packages/a/README.md", Content: "blob a"},
+		{Ref: "develop", Path: "packages/b/README.md", Content: "blob b"},
+	}
+	if len(files) != len(want) {
+		t.Fatalf("fetchExtraFiles() returned %d files, want %d: %+v", len(files), len(want), files)
+	}
+	for i := range want {
+		if files[i] != want[i] {
+			t.Errorf("file %d = %+v, want %+v", i, files[i], want[i])
+		}
+	}
+}
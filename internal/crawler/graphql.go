@@ -0,0 +1,175 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// graphQLBlobsPerQuery bounds the README blobs requested per GraphQL query:
+// 25 repositories with one ref each. Blobs can be large, so batches shrink
+// as more refs are searched, keeping queries well below GitHub's resource
+// limits.
+const graphQLBlobsPerQuery = 175
+
+// readmeCandidates are the README paths guessed for each repository when
+// fetching through GraphQL, in the order GitHub itself prefers them.
+var readmeCandidates = []string{
+	"README.md",
+	"readme.md",
+	"Readme.md",
+	"README.markdown",
+	"README.rst",
+	"README.txt",
+	"README",
+}
+
+// readmeFile is a README fetched ahead of processing.
+type readmeFile struct {
+	Ref     string
+	Path    string
+	Content string
+}
+
+type graphQLRequest struct {
+	Query string `json:"query"`
+}
+
+type graphQLError struct {
+	Message string `json:"message"`
+}
+
+type graphQLResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []graphQLError             `json:"errors"`
+}
+
+type graphQLBlob struct {
+	Text        *string `json:"text"`
+	IsTruncated bool    `json:"isTruncated"`
+}
+
+type graphQLRateLimit struct {
+	Cost      int `json:"cost"`
+	Remaining int `json:"remaining"`
+}
+
+// fetchReadmesGraphQL fetches README blobs for the given repositories in
+// batches, keyed by repository name. Repositories where none of the guessed
+// paths exist on any candidate ref, or whose README GraphQL truncated, are
+// absent from the result and should fall back to REST.
+func fetchReadmesGraphQL(ctx context.Context, client *github.Client, repos []*github.Repository, refs RefSelector, usage *apiUsage) (map[string]readmeFile, error) {
+	readmes := make(map[string]readmeFile, len(repos))
+
+	for _, batch := range readmeBatches(repos, refs) {
+		resp, err := queryGraphQL(ctx, client, buildReadmeQuery(batch, refs))
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range resp.Errors {
+			fmt.Printf("GraphQL warning: %s\n", e.Message)
+		}
+
+		if raw, ok := resp.Data["rateLimit"]; ok {
+			var rl graphQLRateLimit
+			if err := json.Unmarshal(raw, &rl); err == nil {
+				usage.graphQLPoints.Add(int64(rl.Cost))
+			}
+		}
+
+		for i, repo := range batch {
+			raw, ok := resp.Data[fmt.Sprintf("r%d", i)]
+			if !ok || string(raw) == "null" {
+				continue
+			}
+			var blobs map[string]*graphQLBlob
+			if err := json.Unmarshal(raw, &blobs); err != nil {
+				return nil, fmt.Errorf("failed to decode GraphQL result for %s: %w", repo.GetName(), err)
+			}
+		search:
+			for k, ref := range refs.candidates(repo.GetName(), repo.GetDefaultBranch()) {
+				for j, candidate := range readmeCandidates {
+					blob := blobs[fmt.Sprintf("f%d_%d", k, j)]
+					if blob == nil || blob.Text == nil {
+						continue
+					}
+					if blob.IsTruncated {
+						fmt.Printf("README of %s is too large for GraphQL; fetching it via REST.\n", repo.GetName())
+						break search
+					}
+					readmes[repo.GetName()] = readmeFile{Ref: ref, Path: candidate, Content: *blob.Text}
+					break search
+				}
+			}
+		}
+	}
+
+	return readmes, nil
+}
+
+// readmeBatches splits the repositories into batches requesting at most
+// graphQLBlobsPerQuery blobs, one per README candidate on each candidate
+// ref; a repository needing more than that is a batch of its own.
+func readmeBatches(repos []*github.Repository, refs RefSelector) [][]*github.Repository {
+	var batches [][]*github.Repository
+	for start := 0; start < len(repos); {
+		end, blobs := start, 0
+		for end < len(repos) {
+			n := len(refs.candidates(repos[end].GetName(), repos[end].GetDefaultBranch())) * len(readmeCandidates)
+			if end > start && blobs+n > graphQLBlobsPerQuery {
+				break
+			}
+			blobs += n
+			end++
+		}
+		batches = append(batches, repos[start:end])
+		start = end
+	}
+	return batches
+}
+
+// buildReadmeQuery builds a single query that requests every README candidate
+// on every candidate ref for each repository in the batch, using aliases r<i>
+// for repositories and f<ref>_<file> for blobs.
+func buildReadmeQuery(repos []*github.Repository, refs RefSelector) string {
+	var b strings.Builder
+	b.WriteString("query {\n")
+	for i, repo := range repos {
+		fmt.Fprintf(&b, "  r%d: repository(owner: %s, name: %s) {\n", i, graphQLString(repo.GetOwner().GetLogin()), graphQLString(repo.GetName()))
+		for k, ref := range refs.candidates(repo.GetName(), repo.GetDefaultBranch()) {
+			for j, candidate := range readmeCandidates {
+				fmt.Fprintf(&b, "    f%d_%d: object(expression: %s) { ... on Blob { text isTruncated } }\n", k, j, graphQLString(ref+":"+candidate))
+			}
+		}
+		b.WriteString("  }\n")
+	}
+	b.WriteString("  rateLimit { cost remaining }\n")
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func queryGraphQL(ctx context.Context, client *github.Client, query string) (*graphQLResponse, error) {
+	req, err := client.NewRequest("POST", "graphql", graphQLRequest{Query: query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build GraphQL request: %w", err)
+	}
+
+	var resp graphQLResponse
+	if _, err := client.Do(ctx, req, &resp); err != nil {
+		return nil, fmt.Errorf("GraphQL query failed: %w", err)
+	}
+	if resp.Data == nil && len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("GraphQL query failed: %s", resp.Errors[0].Message)
+	}
+	return &resp, nil
+}
+
+// graphQLString quotes s as a GraphQL string literal. JSON string escaping is
+// a valid subset of GraphQL's.
+func graphQLString(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
@@ -0,0 +1,108 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+)
+
+func TestFetchReadmesGraphQL(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/graphql" {
+			t.Errorf("unexpected request path %q", r.URL.Path)
+		}
+		var req graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if !strings.Contains(req.Query, `"release:README.rst"`) || !strings.Contains(req.Query, `"main:README.md"`) || !strings.Contains(req.Query, "isTruncated") {
+			t.Errorf("query does not request every ref and README candidate:\n%s", req.Query)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": {
+			"r0": {"f0_0": {"text": "# alpha"}, "f1_0": {"text": "# alpha main"}},
+			"r1": {"f0_0": null, "f1_4": {"text": "beta"}},
+			"r2": {"f0_0": null},
+			"r3": {"f0_0": {"text": "# delta, cut off", "isTruncated": true}, "f1_0": {"text": "# delta main"}},
+			"rateLimit": {"cost": 1, "remaining": 4999}
+		}}`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	baseURL, _ := url.Parse(server.URL + "/")
+	client.BaseURL = baseURL
+
+	repos := []*github.Repository{
+		{Name: github.String("alpha"), DefaultBranch: github.String("main"), Owner: &github.User{Login: github.String("org")}},
+		{Name: github.String("beta"), DefaultBranch: github.String("main"), Owner: &github.User{Login: github.String("org")}},
+		{Name: github.String("gamma"), DefaultBranch: github.String("main"), Owner: &github.User{Login: github.String("org")}},
+		{Name: github.String("delta"), DefaultBranch: github.String("main"), Owner: &github.User{Login: github.String("org")}},
+	}
+	refs := RefSelector{Default: []string{"release"}}
+
+	usage := &apiUsage{}
+	readmes, err := fetchReadmesGraphQL(context.Background(), client, repos, refs, usage)
+	if err != nil {
+		t.Fatalf("fetchReadmesGraphQL() error = %v", err)
+	}
+
+	if got := readmes["alpha"]; got.Ref != "release" || got.Path != "README.md" || got.Content != "# alpha" {
+		t.Errorf("alpha readme = %+v", got)
+	}
+	if got := readmes["beta"]; got.Ref != "main" || got.Path != "README.rst" || got.Content != "beta" {
+		t.Errorf("beta readme = %+v", got)
+	}
+	if _, ok := readmes["gamma"]; ok {
+		t.Errorf("gamma should fall back to REST")
+	}
+	if _, ok := readmes["delta"]; ok {
+		t.Errorf("delta has a truncated README and should fall back to REST")
+	}
+	if got := usage.graphQLPoints.Load(); got != 1 {
+		t.Errorf("graphQLPoints = %d, want 1", got)
+	}
+}
+
+func TestReadmeBatches(t *testing.T) {
+	t.Parallel()
+
+	var repos []*github.Repository
+	for i := range 60 {
+		repos = append(repos, &github.Repository{Name: github.String(fmt.Sprintf("repo%d", i)), DefaultBranch: github.String("main")})
+	}
+	manyRefs := make([]string, 30)
+	for i := range manyRefs {
+		manyRefs[i] = fmt.Sprintf("v%d", i)
+	}
+
+	tests := []struct {
+		name  string
+		refs  RefSelector
+		sizes []int
+	}{
+		{"default branch", RefSelector{}, []int{25, 25, 10}},
+		{"three refs", RefSelector{Default: []string{"release", "develop"}}, []int{8, 8, 8, 8, 8, 8, 8, 4}},
+		{"oversized override", RefSelector{Overrides: map[string][]string{"repo1": manyRefs}}, []int{1, 1, 25, 25, 8}},
+	}
+	for _, tt := range tests {
+		var sizes []int
+		total := 0
+		for _, batch := range readmeBatches(repos, tt.refs) {
+			sizes = append(sizes, len(batch))
+			total += len(batch)
+		}
+		if fmt.Sprint(sizes) != fmt.Sprint(tt.sizes) || total != len(repos) {
+			t.Errorf("%s: batch sizes = %v, want %v", tt.name, sizes, tt.sizes)
+		}
+	}
+}
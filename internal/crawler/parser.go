@@ -1,6 +1,7 @@
 package crawler
 
 import (
+	"bytes"
 	"net/url"
 	"path"
 	"regexp"
@@ -39,6 +40,7 @@ func extractBadges(content []byte, detector badgeDetector) []models.Badge {
 						AltText:   string(img.Text(content)),
 						ImageURL:  string(img.Destination),
 						TargetURL: string(link.Destination),
+						Line:      imageLine(content, img),
 					}
 					if !detector.isBadgeCandidate(badge) {
 						continue
@@ -54,12 +56,15 @@ func extractBadges(content []byte, detector badgeDetector) []models.Badge {
 	// 2. Regex fallback for HTML badges: <a href="..."><img src="..." alt="..."></a>
 	// This is a simple regex and might not catch all edge cases, but covers the standard pattern.
 	htmlBadgeRegex := regexp.MustCompile(`<a\s+href="([^"]+)"[^>]*>\s*<img\s+src="([^"]+)"(?:\s+alt="([^"]*)")?[^>]*>\s*</a>`)
-	matches := htmlBadgeRegex.FindAllSubmatch(content, -1)
+	matches := htmlBadgeRegex.FindAllSubmatchIndex(content, -1)
 	for _, match := range matches {
 		badge := models.Badge{
-			TargetURL: string(match[1]),
-			ImageURL:  string(match[2]),
-			AltText:   string(match[3]),
+			TargetURL: string(content[match[2]:match[3]]),
+			ImageURL:  string(content[match[4]:match[5]]),
+			Line:      lineAt(content, match[0]),
+		}
+		if match[6] >= 0 {
+			badge.AltText = string(content[match[6]:match[7]])
 		}
 		if !detector.isBadgeCandidate(badge) {
 			continue
@@ -72,6 +77,28 @@ func extractBadges(content []byte, detector badgeDetector) []models.Badge {
 	return badges
 }
 
+// imageLine returns the 1-based line of an image in the Markdown source.
+// Inline nodes carry no position, so the image URL is searched for from the
+// start of the enclosing block.
+func imageLine(content []byte, img *ast.Image) int {
+	start := 0
+	for n := img.Parent(); n != nil; n = n.Parent() {
+		if n.Type() == ast.TypeBlock && n.Lines().Len() > 0 {
+			start = n.Lines().At(0).Start
+			break
+		}
+	}
+	if i := bytes.Index(content[start:], img.Destination); i >= 0 {
+		start += i
+	}
+	return lineAt(content, start)
+}
+
+// lineAt returns the 1-based line containing the byte offset.
+func lineAt(content []byte, offset int) int {
+	return bytes.Count(content[:offset], []byte("\n")) + 1
+}
+
 func normalizeBadge(b *models.Badge) {
 	if u, err := url.Parse(b.ImageURL); err == nil {
 		b.HostImage = u.Host
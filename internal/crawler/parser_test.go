@@ -61,3 +61,32 @@ func TestExtractBadges(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractBadgesLines(t *testing.T) {
+	t.Parallel()
+
+	content := `# project
+
+[![License](https://img.shields.io/badge/license-MIT-blue.svg)](LICENSE)
+[![Go](https://github.com/example/project/actions/workflows/go.yml/badge.svg)](https://github.com/example/project/actions)
+
+Some text.
+
+<a href="https://codecov.io/gh/example/project"><img src="https://codecov.io/gh/example/project/badge.svg" alt="Coverage"></a>
+`
+	detector := badgeDetector{domains: map[string]struct{}{"img.shields.io": {}}}
+
+	badges := extractBadges([]byte(content), detector)
+	want := []int{3, 4, 8}
+	if len(badges) != len(want) {
+		t.Fatalf("extractBadges() returned %d badges, want %d", len(badges), len(want))
+	}
+	for i, b := range badges {
+		if b.Line != want[i] {
+			t.Errorf("badge %d (%s) Line = %d, want %d", i, b.ImageURL, b.Line, want[i])
+		}
+	}
+	if badges[2].AltText != "Coverage" {
+		t.Errorf("html badge AltText = %q, want Coverage", badges[2].AltText)
+	}
+}
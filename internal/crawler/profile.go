@@ -0,0 +1,45 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+	"github.com/google/go-github/v57/github"
+)
+
+// Organization profile README location, shown on the organization's GitHub page.
+const (
+	profileRepo = ".github"
+	profilePath = "profile/README.md"
+)
+
+// processOrgProfile extracts badges from the organization profile README into
+// an organization-level record. A missing profile README is recorded with
+// ReadmeFound set to false.
+func processOrgProfile(ctx context.Context, client *github.Client, opts Options) (*models.OrganizationData, error) {
+	data := models.OrganizationData{
+		Organization: opts.OrgName,
+		ProfileURL:   fmt.Sprintf("https://github.com/%s", opts.OrgName),
+	}
+
+	content, _, resp, err := client.Repositories.GetContents(ctx, opts.OrgName, profileRepo, profilePath, nil)
+	switch {
+	case err != nil && resp != nil && resp.StatusCode == http.StatusNotFound:
+		fmt.Println("No organization profile README found.")
+	case err != nil:
+		return nil, fmt.Errorf("failed to fetch organization profile README: %w", err)
+	case content != nil:
+		text, err := content.GetContent()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode organization profile README: %w", err)
+		}
+		data.ReadmeFound = true
+		data.ReadmeURL = content.GetHTMLURL()
+		data.Badges = extractFileBadges(readmeFile{Path: profilePath, Content: text}, badgeDetector{domains: opts.BadgeDomains})
+		fmt.Printf("Found %d badges in organization profile README.\n", len(data.Badges))
+	}
+
+	return &data, nil
+}
@@ -0,0 +1,93 @@
+package crawler
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RefSelector chooses which git refs are searched for a repository's README.
+// Candidates are tried in priority order and the repository's default branch
+// is always the final fallback.
+type RefSelector struct {
+	// Default lists refs tried for every repository without an override.
+	Default []string
+	// Overrides maps lowercase repository names to their own ref list.
+	Overrides map[string][]string
+}
+
+// ParseRefList splits a comma separated list of refs.
+func ParseRefList(raw string) []string {
+	var refs []string
+	for ref := range strings.SplitSeq(raw, ",") {
+		if ref = strings.TrimSpace(ref); ref != "" {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// candidates returns the refs to try for a repository, in priority order and
+// without duplicates.
+func (s RefSelector) candidates(repoName, defaultBranch string) []string {
+	refs := s.Default
+	if override, ok := s.Overrides[strings.ToLower(repoName)]; ok {
+		refs = override
+	}
+
+	seen := make(map[string]struct{}, len(refs)+1)
+	var result []string
+	for _, ref := range append(append([]string{}, refs...), defaultBranch) {
+		if ref == "" {
+			continue
+		}
+		if _, ok := seen[ref]; ok {
+			continue
+		}
+		seen[ref] = struct{}{}
+		result = append(result, ref)
+	}
+	return result
+}
+
+// refList accepts either a single ref or a list of refs in YAML.
+type refList []string
+
+func (l *refList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = ParseRefList(node.Value)
+		return nil
+	}
+	var refs []string
+	if err := node.Decode(&refs); err != nil {
+		return err
+	}
+	*l = refs
+	return nil
+}
+
+type refOverrideConfig struct {
+	Repos map[string]refList `yaml:"repos"`
+}
+
+// LoadRefOverrides reads a YAML file mapping repository names to the ref, or
+// list of refs in priority order, to index for that repository.
+func LoadRefOverrides(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read ref overrides: %w", err)
+	}
+
+	var config refOverrideConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("parse ref overrides: %w", err)
+	}
+
+	overrides := make(map[string][]string, len(config.Repos))
+	for repo, refs := range config.Repos {
+		overrides[strings.ToLower(repo)] = refs
+	}
+	return overrides, nil
+}
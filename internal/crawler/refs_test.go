@@ -0,0 +1,62 @@
+package crawler
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRefSelectorCandidates(t *testing.T) {
+	t.Parallel()
+
+	selector := RefSelector{
+		Default:   []string{"release", "main"},
+		Overrides: map[string][]string{"monorepo": {"develop"}},
+	}
+
+	tests := []struct {
+		name          string
+		repo          string
+		defaultBranch string
+		want          []string
+	}{
+		{name: "default list falls back to default branch", repo: "service", defaultBranch: "trunk", want: []string{"release", "main", "trunk"}},
+		{name: "default branch is not repeated", repo: "service", defaultBranch: "main", want: []string{"release", "main"}},
+		{name: "override replaces default list", repo: "MonoRepo", defaultBranch: "main", want: []string{"develop", "main"}},
+		{name: "empty repository has no default branch", repo: "empty", defaultBranch: "", want: []string{"release", "main"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := selector.candidates(tt.repo, tt.defaultBranch); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("candidates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRefOverrides(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "refs.yaml")
+	content := "repos:\n  MonoRepo: develop\n  api: [release/2.x, main]\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	overrides, err := LoadRefOverrides(path)
+	if err != nil {
+		t.Fatalf("LoadRefOverrides() error = %v", err)
+	}
+
+	want := map[string][]string{
+		"monorepo": {"develop"},
+		"api":      {"release/2.x", "main"},
+	}
+	if !reflect.DeepEqual(overrides, want) {
+		t.Fatalf("LoadRefOverrides() = %v, want %v", overrides, want)
+	}
+}
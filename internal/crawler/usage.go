@@ -0,0 +1,35 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// apiUsage tracks GitHub API consumption during a crawl.
+type apiUsage struct {
+	restCalls      atomic.Int64
+	graphQLQueries atomic.Int64
+	graphQLPoints  atomic.Int64
+}
+
+func (u *apiUsage) String() string {
+	return fmt.Sprintf("%d REST calls, %d GraphQL queries (%d points)",
+		u.restCalls.Load(), u.graphQLQueries.Load(), u.graphQLPoints.Load())
+}
+
+// countingTransport counts outgoing requests by API type.
+type countingTransport struct {
+	base  http.RoundTripper
+	usage *apiUsage
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if strings.HasSuffix(req.URL.Path, "/graphql") {
+		t.usage.graphQLQueries.Add(1)
+	} else {
+		t.usage.restCalls.Add(1)
+	}
+	return t.base.RoundTrip(req)
+}
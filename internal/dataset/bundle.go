@@ -0,0 +1,200 @@
+package dataset
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+)
+
+// Bundle format identifiers.
+const (
+	BundleFormat        = "badgeindexer-bundle"
+	BundleFormatVersion = 1
+)
+
+// maxLineSize bounds a single JSONL record; a repository with hundreds of
+// badges is still far below this.
+const maxLineSize = 16 << 20
+
+// isJSONL reports whether path uses the JSON Lines layout: a header record on
+// the first line followed by one repository per line. Any other path holds a
+// single JSON document. Either may be gzip compressed with a .gz suffix.
+func isJSONL(path string) bool {
+	return strings.HasSuffix(strings.TrimSuffix(path, ".gz"), ".jsonl")
+}
+
+// WriteBundle writes the crawl to a single file, as JSON or JSON Lines
+// depending on the extension, gzip compressed when the path ends in .gz.
+func WriteBundle(path string, bundle *models.Bundle) (err error) {
+	bundle.Format = BundleFormat
+	bundle.FormatVersion = BundleFormatVersion
+	stampSchemaVersion(bundle)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle %s: %w", path, err)
+	}
+	defer func() {
+		if cerr := file.Close(); err == nil && cerr != nil {
+			err = fmt.Errorf("failed to close bundle %s: %w", path, cerr)
+		}
+	}()
+
+	var w io.Writer = file
+	if strings.HasSuffix(path, ".gz") {
+		gz := gzip.NewWriter(file)
+		defer func() {
+			if cerr := gz.Close(); err == nil && cerr != nil {
+				err = fmt.Errorf("failed to compress bundle %s: %w", path, cerr)
+			}
+		}()
+		w = gz
+	}
+
+	if !isJSONL(path) {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(bundle); err != nil {
+			return fmt.Errorf("failed to encode bundle %s: %w", path, err)
+		}
+		return nil
+	}
+
+	header := *bundle
+	header.Repositories = nil
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(header); err != nil {
+		return fmt.Errorf("failed to encode bundle header: %w", err)
+	}
+	for _, repo := range bundle.Repositories {
+		if err := encoder.Encode(repo); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", repo.Repository, err)
+		}
+	}
+	return nil
+}
+
+// ReadBundle reads a bundle written by WriteBundle, migrating records from
+// older schema versions.
+func ReadBundle(path string) (*models.Bundle, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress bundle %s: %w", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	rep := &report{}
+	defer rep.print()
+
+	if !isJSONL(path) {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle %s: %w", path, err)
+		}
+		record, err := decodeObject(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode bundle %s: %w", path, err)
+		}
+		return decodeBundle(record, nil, rep)
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read bundle %s: %w", path, err)
+		}
+		return nil, errors.New("bundle is empty")
+	}
+	header, err := decodeObject(scanner.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode bundle header: %w", err)
+	}
+
+	var repos []any
+	for line := 2; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		record, err := decodeObject(scanner.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode bundle line %d: %w", line, err)
+		}
+		repos = append(repos, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read bundle %s: %w", path, err)
+	}
+
+	return decodeBundle(header, repos, rep)
+}
+
+// decodeBundle decodes a bundle header and its repository records, migrating
+// each record separately since they carry their own schema versions. Records
+// embedded in the header are used when repos is nil.
+func decodeBundle(header map[string]any, repos []any, r *report) (*models.Bundle, error) {
+	if repos == nil {
+		repos, _ = header["repositories"].([]any)
+	}
+	profile, _ := header["profile"].(map[string]any)
+	delete(header, "repositories")
+	delete(header, "profile")
+
+	bundle, err := decodeRecordObject[models.Bundle](header, "bundle", nil, r)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBundleHeader(&bundle); err != nil {
+		return nil, err
+	}
+
+	if profile != nil {
+		org, err := decodeRecordObject[models.OrganizationData](profile, "profile", nil, r)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Profile = &org
+	}
+
+	for i, raw := range repos {
+		name := fmt.Sprintf("repositories[%d]", i)
+		record, ok := raw.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("failed to decode %s: expected a JSON object", name)
+		}
+		repo, err := decodeRecordObject[models.RepositoryData](record, name, repositoryMigrations, r)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Repositories = append(bundle.Repositories, repo)
+	}
+
+	return &bundle, nil
+}
+
+func checkBundleHeader(bundle *models.Bundle) error {
+	if bundle.Format != BundleFormat {
+		return fmt.Errorf("not a badgeindexer bundle (format %q)", bundle.Format)
+	}
+	if bundle.FormatVersion > BundleFormatVersion {
+		return fmt.Errorf("bundle format version %d is newer than supported version %d", bundle.FormatVersion, BundleFormatVersion)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package dataset
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+)
+
+func sampleBundle() *models.Bundle {
+	return &models.Bundle{
+		Organization: "example-org",
+		CrawledAt:    time.Date(2026, 10, 1, 12, 30, 0, 0, time.UTC),
+		Config: models.CrawlConfig{
+			Strategy:   "graphql",
+			Refs:       []string{"release"},
+			ExtraPaths: []string{"docs/index.md"},
+		},
+		Profile: &models.OrganizationData{
+			Organization: "example-org",
+			ProfileURL:   "https://github.com/example-org",
+			ReadmeFound:  true,
+			Badges:       []models.Badge{{AltText: "Org", ImageURL: "https://img.shields.io/badge/org-yes-blue"}},
+		},
+		Repositories: []models.RepositoryData{
+			{
+				Repository:    "alpha",
+				RepositoryURL: "https://github.com/example-org/alpha",
+				DefaultBranch: "main",
+				Ref:           "main",
+				ReadmeFound:   true,
+				Badges:        []models.Badge{{AltText: "License", ImageURL: "https://img.shields.io/badge/license-MIT-blue.svg", Source: "README.md"}},
+			},
+			{
+				Repository:    "timestamp",
+				RepositoryURL: "https://github.com/example-org/timestamp",
+				DefaultBranch: "main",
+			},
+		},
+	}
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"crawl.json", "crawl.json.gz", "crawl.jsonl", "crawl.jsonl.gz"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), name)
+			want := sampleBundle()
+			if err := WriteBundle(path, want); err != nil {
+				t.Fatalf("WriteBundle() error = %v", err)
+			}
+
+			got, err := ReadBundle(path)
+			if err != nil {
+				t.Fatalf("ReadBundle() error = %v", err)
+			}
+			if got.Format != BundleFormat || got.FormatVersion != BundleFormatVersion {
+				t.Errorf("format = %q v%d", got.Format, got.FormatVersion)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("ReadBundle() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestDirectoryRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	want := sampleBundle()
+	// Repositories named like the organization and timestamp records
+	want.Repositories = append(want.Repositories, models.RepositoryData{
+		Repository:    "organization",
+		RepositoryURL: "https://github.com/example-org/organization",
+		DefaultBranch: "main",
+	})
+	// A crawl in the older layout, rewritten in the current one
+	if err := os.WriteFile(filepath.Join(dir, TimestampFile), []byte(`{"last_crawled":"2020-01-01T00:00:00Z"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteDirectory(dir, want); err != nil {
+		t.Fatalf("WriteDirectory() error = %v", err)
+	}
+
+	got, err := ReadDirectory(dir)
+	if err != nil {
+		t.Fatalf("ReadDirectory() error = %v", err)
+	}
+	if !got.CrawledAt.Equal(want.CrawledAt) {
+		t.Errorf("CrawledAt = %v, want %v", got.CrawledAt, want.CrawledAt)
+	}
+	if got.Organization != want.Organization || !reflect.DeepEqual(got.Profile, want.Profile) {
+		t.Errorf("Profile = %+v, want %+v", got.Profile, want.Profile)
+	}
+	var names []string
+	for _, repo := range got.Repositories {
+		names = append(names, repo.Repository)
+	}
+	if fmt.Sprint(names) != "[alpha organization timestamp]" {
+		t.Errorf("Repositories = %v, want alpha, organization and timestamp", names)
+	}
+}
+
+func TestReadDirectoryOlderLayout(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"alpha.json":     `{"schema_version":2,"repository":"alpha","repository_url":"https://github.com/example-org/alpha","default_branch":"main","readme_found":true,"badges":[]}`,
+		TimestampFile:    `{"schema_version":2,"last_crawled":"2026-10-01T12:30:00Z"}`,
+		OrganizationFile: `{"schema_version":2,"organization":"example-org","profile_url":"https://github.com/example-org","readme_found":false,"badges":[]}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := ReadDirectory(dir)
+	if err != nil {
+		t.Fatalf("ReadDirectory() error = %v", err)
+	}
+	if len(got.Repositories) != 1 || got.Organization != "example-org" || got.CrawledAt.IsZero() {
+		t.Errorf("ReadDirectory() = %d repositories, organization %q, crawled %v; want alpha, example-org and the timestamp", len(got.Repositories), got.Organization, got.CrawledAt)
+	}
+}
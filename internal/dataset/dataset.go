@@ -0,0 +1,290 @@
+// Package dataset reads and writes crawl results, either as a directory of
+// per-repository JSON files or as a single bundle file.
+package dataset
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+)
+
+// Files in a crawl directory that do not hold repository data. They live in
+// MetaDir, where no repository file can collide with them; crawls written
+// before it kept them next to the repository files.
+const (
+	MetaDir          = "_meta"
+	TimestampFile    = "timestamp.json"
+	OrganizationFile = "organization.json"
+)
+
+// Source identifies where a crawl is read from. SQLite takes precedence over
+// Bundle, which takes precedence over Dir.
+type Source struct {
+	Dir    string
+	Bundle string
+	SQLite string
+	// Run selects a recorded SQLite run; zero means the latest.
+	Run int64
+}
+
+// ParseSource interprets a crawl location given on the command line: a
+// directory, a SQLite store (.db, .sqlite or .sqlite3, optionally followed by
+// #<run ID>) or a bundle file.
+func ParseSource(spec string) (Source, error) {
+	path, run, hasRun := strings.Cut(spec, "#")
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".db", ".sqlite", ".sqlite3":
+		src := Source{SQLite: path}
+		if hasRun {
+			id, err := strconv.ParseInt(run, 10, 64)
+			if err != nil || id <= 0 {
+				return Source{}, fmt.Errorf("invalid run ID %q in %s", run, spec)
+			}
+			src.Run = id
+		}
+		return src, nil
+	}
+
+	info, err := os.Stat(spec)
+	if err != nil {
+		return Source{}, fmt.Errorf("failed to open %s: %w", spec, err)
+	}
+	if info.IsDir() {
+		return Source{Dir: spec}, nil
+	}
+	return Source{Bundle: spec}, nil
+}
+
+// String describes the source for log messages.
+func (s Source) String() string {
+	switch {
+	case s.SQLite != "" && s.Run != 0:
+		return fmt.Sprintf("%s#%d", s.SQLite, s.Run)
+	case s.SQLite != "":
+		return s.SQLite
+	case s.Bundle != "":
+		return s.Bundle
+	default:
+		return s.Dir
+	}
+}
+
+// Load reads a crawl from the source; for SQLite this is the selected run.
+func Load(src Source) (*models.Bundle, error) {
+	switch {
+	case src.SQLite != "":
+		return ReadSQLiteRun(src.SQLite, src.Run)
+	case src.Bundle != "":
+		return ReadBundle(src.Bundle)
+	default:
+		return ReadDirectory(src.Dir)
+	}
+}
+
+// Save writes a crawl read from the source back to it, for phases that
+// annotate an existing crawl. For SQLite this updates the badges of the
+// selected run in place rather than recording a new run.
+func Save(src Source, bundle *models.Bundle) error {
+	switch {
+	case src.SQLite != "":
+		return UpdateSQLiteBadges(src.SQLite, src.Run, bundle)
+	case src.Bundle != "":
+		return WriteBundle(src.Bundle, bundle)
+	default:
+		return WriteDirectory(src.Dir, bundle)
+	}
+}
+
+// WriteDirectory writes one JSON file per repository plus, in MetaDir,
+// timestamp.json and, when present, organization.json.
+func WriteDirectory(dir string, bundle *models.Bundle) error {
+	stampSchemaVersion(bundle)
+	metaDir := filepath.Join(dir, MetaDir)
+	if err := os.MkdirAll(metaDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	// Records left by the older layout would be read as repositories now
+	for _, name := range []string{TimestampFile, OrganizationFile} {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s: %w", name, err)
+		}
+	}
+
+	for _, repo := range bundle.Repositories {
+		filename := filepath.Join(dir, fmt.Sprintf("%s.json", NormalizeRepoName(repo.Repository)))
+		if err := writeJSONFile(filename, repo); err != nil {
+			return err
+		}
+	}
+
+	if bundle.Profile != nil {
+		if err := writeJSONFile(filepath.Join(metaDir, OrganizationFile), bundle.Profile); err != nil {
+			return err
+		}
+	}
+
+	timestampData := timestampRecord{
+		SchemaVersion: bundle.SchemaVersion,
+		LastCrawled:   bundle.CrawledAt.Format(time.RFC3339Nano),
+	}
+	return writeJSONFile(filepath.Join(metaDir, TimestampFile), timestampData)
+}
+
+// timestampRecord is the content of timestamp.json.
+type timestampRecord struct {
+	SchemaVersion int    `json:"schema_version"`
+	LastCrawled   string `json:"last_crawled"`
+}
+
+// stampSchemaVersion marks every record in the bundle with the current schema
+// version before it is written.
+func stampSchemaVersion(bundle *models.Bundle) {
+	bundle.SchemaVersion = models.SchemaVersion
+	if bundle.Profile != nil {
+		bundle.Profile.SchemaVersion = models.SchemaVersion
+	}
+	for i := range bundle.Repositories {
+		bundle.Repositories[i].SchemaVersion = models.SchemaVersion
+	}
+}
+
+// ReadDirectory reads a crawl directory written by WriteDirectory, migrating
+// records from older schema versions. Missing timestamp or organization files
+// leave the corresponding fields empty. Directories without MetaDir are read
+// in the older layout, where those files sit next to the repository files.
+func ReadDirectory(dir string) (*models.Bundle, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list input files: %w", err)
+	}
+
+	r := &report{}
+	defer r.print()
+
+	bundle := &models.Bundle{SchemaVersion: models.SchemaVersion}
+	metaDir := dir
+	if info, err := os.Stat(filepath.Join(dir, MetaDir)); err == nil && info.IsDir() {
+		metaDir = filepath.Join(dir, MetaDir)
+	}
+	bundle.CrawledAt = readTimestamp(filepath.Join(metaDir, TimestampFile))
+	if err := readOrganization(filepath.Join(metaDir, OrganizationFile), bundle, r); err != nil {
+		return nil, err
+	}
+
+	for _, f := range files {
+		name := filepath.Base(f)
+		if metaDir == dir && (name == TimestampFile || name == OrganizationFile) {
+			continue
+		}
+
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", f, err)
+		}
+		repo, err := decodeRecord[models.RepositoryData](data, name, repositoryMigrations, r)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Repositories = append(bundle.Repositories, repo)
+	}
+
+	return bundle, nil
+}
+
+// readOrganization reads the organization record into the bundle, if there
+// is one. An unreadable record is reported and ignored.
+func readOrganization(path string, bundle *models.Bundle, r *report) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	org, err := decodeRecord[models.OrganizationData](data, OrganizationFile, nil, r)
+	if err != nil {
+		r.warn("ignoring unreadable %s: %v", OrganizationFile, err)
+		return nil
+	}
+	bundle.Profile = &org
+	bundle.Organization = org.Organization
+	return nil
+}
+
+// OrganizationName returns the organization a crawl belongs to. Crawls that
+// predate the organization record fall back to the owner in the first
+// repository URL (e.g., https://github.com/OrgName/RepoName).
+func OrganizationName(bundle *models.Bundle) string {
+	if bundle.Organization != "" {
+		return bundle.Organization
+	}
+	for _, repo := range bundle.Repositories {
+		if repo.RepositoryURL == "" {
+			continue
+		}
+		if u, err := url.Parse(repo.RepositoryURL); err == nil {
+			parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+			if len(parts) >= 1 {
+				return parts[0]
+			}
+		}
+	}
+	return ""
+}
+
+// NormalizeRepoName converts a repository name into a file name stem.
+func NormalizeRepoName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "/", "-")
+}
+
+// readTimestamp parses the last_crawled time, returning the zero time when it
+// is missing or malformed.
+func readTimestamp(path string) time.Time {
+	var data timestampRecord
+	if err := readJSONFile(path, &data); err != nil {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, data.LastCrawled)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func readJSONFile(path string, v any) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	if err := json.NewDecoder(file).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return nil
+}
+
+func writeJSONFile(path string, v any) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package dataset
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseSource(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	bundle := filepath.Join(dir, "crawl.jsonl.gz")
+	if err := os.WriteFile(bundle, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		spec    string
+		want    Source
+		wantErr bool
+	}{
+		{spec: dir, want: Source{Dir: dir}},
+		{spec: bundle, want: Source{Bundle: bundle}},
+		{spec: "history.db", want: Source{SQLite: "history.db"}},
+		{spec: "history.sqlite#12", want: Source{SQLite: "history.sqlite", Run: 12}},
+		{spec: "history.db#latest", wantErr: true},
+		{spec: filepath.Join(dir, "missing"), wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSource(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSource(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSource(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
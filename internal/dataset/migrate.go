@@ -0,0 +1,228 @@
+package dataset
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+)
+
+// legacySchemaVersion is assumed for records written before schema_version
+// existed.
+const legacySchemaVersion = 1
+
+// migration upgrades a decoded record from one schema version to the next.
+type migration struct {
+	from        int
+	description string
+	apply       func(record map[string]any)
+}
+
+// repositoryMigrations upgrade RepositoryData records, in order.
+var repositoryMigrations = []migration{
+	{
+		from:        1,
+		description: "record the default branch as the indexed ref",
+		apply: func(record map[string]any) {
+			// Version 1 crawls always read the README from the default branch
+			if ref, _ := record["ref"].(string); ref == "" {
+				record["ref"] = record["default_branch"]
+			}
+		},
+	},
+}
+
+// report collects notices while reading a crawl. Migration notices are
+// counted rather than repeated, since a legacy crawl migrates every record.
+type report struct {
+	migrated      map[string]int
+	migratedOrder []string
+	warnings      []string
+}
+
+func (r *report) migrate(description string) {
+	if r.migrated == nil {
+		r.migrated = make(map[string]int)
+	}
+	if _, ok := r.migrated[description]; !ok {
+		r.migratedOrder = append(r.migratedOrder, description)
+	}
+	r.migrated[description]++
+}
+
+func (r *report) warn(format string, args ...any) {
+	r.warnings = append(r.warnings, fmt.Sprintf(format, args...))
+}
+
+// print writes the report to standard error, keeping standard output free
+// for the reports of commands such as -diff.
+func (r *report) print() {
+	for _, description := range r.migratedOrder {
+		fmt.Fprintf(os.Stderr, "Migrated %d records: %s\n", r.migrated[description], description)
+	}
+	for _, w := range r.warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+}
+
+// decodeRecord decodes a crawl record of type T, upgrading it in memory to the
+// current schema version.
+func decodeRecord[T any](data []byte, name string, migrations []migration, r *report) (T, error) {
+	record, err := decodeObject(data)
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf("failed to decode %s: %w", name, err)
+	}
+	return decodeRecordObject[T](record, name, migrations, r)
+}
+
+// decodeRecordObject is decodeRecord for an already parsed JSON object.
+func decodeRecordObject[T any](record map[string]any, name string, migrations []migration, r *report) (T, error) {
+	var result T
+	if err := migrateRecord(record, name, migrations, r); err != nil {
+		return result, err
+	}
+	for _, field := range unknownFields(record, reflect.TypeFor[T]()) {
+		r.warn("%s: ignoring unknown field %q", name, field)
+	}
+
+	if err := remarshal(record, &result); err != nil {
+		return result, fmt.Errorf("failed to decode %s: %w", name, err)
+	}
+	return result, nil
+}
+
+// migrateRecord applies every migration from the record's version onwards and
+// stamps it with the current version.
+func migrateRecord(record map[string]any, name string, migrations []migration, r *report) error {
+	version, err := schemaVersion(record)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	if version > models.SchemaVersion {
+		r.warn("%s: schema_version %d is newer than supported version %d; reading it on a best-effort basis", name, version, models.SchemaVersion)
+		return nil
+	}
+
+	for _, m := range migrations {
+		if m.from < version {
+			continue
+		}
+		m.apply(record)
+		r.migrate(fmt.Sprintf("schema_version %d to %d: %s", m.from, m.from+1, m.description))
+	}
+	record["schema_version"] = models.SchemaVersion
+	return nil
+}
+
+func schemaVersion(record map[string]any) (int, error) {
+	raw, ok := record["schema_version"]
+	if !ok || raw == nil {
+		return legacySchemaVersion, nil
+	}
+	number, ok := raw.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("schema_version must be a number, got %v", raw)
+	}
+	version, err := number.Int64()
+	if err != nil || version < legacySchemaVersion {
+		return 0, fmt.Errorf("invalid schema_version %v", raw)
+	}
+	return int(version), nil
+}
+
+// unknownFields lists the paths of fields in record that have no matching JSON
+// field in t, descending into nested objects and arrays of objects.
+func unknownFields(record map[string]any, t reflect.Type) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := jsonFields(t)
+	keys := make([]string, 0, len(record))
+	for key := range record {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var unknown []string
+	for _, key := range keys {
+		field, ok := fields[key]
+		if !ok {
+			unknown = append(unknown, key)
+			continue
+		}
+
+		switch value := record[key].(type) {
+		case map[string]any:
+			for _, nested := range unknownFields(value, field) {
+				unknown = append(unknown, key+"."+nested)
+			}
+		case []any:
+			elem := field
+			if elem.Kind() == reflect.Slice {
+				elem = elem.Elem()
+			}
+			for i, item := range value {
+				obj, ok := item.(map[string]any)
+				if !ok {
+					continue
+				}
+				for _, nested := range unknownFields(obj, elem) {
+					unknown = append(unknown, fmt.Sprintf("%s[%d].%s", key, i, nested))
+				}
+			}
+		}
+	}
+	return unknown
+}
+
+// jsonFields maps the JSON names of a struct's fields to their types.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+func decodeObject(data []byte) (map[string]any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var record map[string]any
+	if err := decoder.Decode(&record); err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+	return record, nil
+}
+
+func remarshal(record map[string]any, v any) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
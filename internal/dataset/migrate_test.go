@@ -0,0 +1,129 @@
+package dataset
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+)
+
+func TestDecodeRecordMigratesLegacyRepository(t *testing.T) {
+	t.Parallel()
+
+	legacy := `{
+		"repository": "alpha",
+		"repository_url": "https://github.com/example-org/alpha",
+		"default_branch": "main",
+		"readme_found": true,
+		"badges": [{"alt_text": "License", "image_url": "https://img.shields.io/badge/license-MIT-blue.svg", "target_url": "", "host_image": "img.shields.io", "host_target": "", "color": "blue"}],
+		"stars": 12
+	}`
+
+	r := &report{}
+	repo, err := decodeRecord[models.RepositoryData]([]byte(legacy), "alpha.json", repositoryMigrations, r)
+	if err != nil {
+		t.Fatalf("decodeRecord() error = %v", err)
+	}
+
+	if repo.SchemaVersion != models.SchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", repo.SchemaVersion, models.SchemaVersion)
+	}
+	if repo.Ref != "main" {
+		t.Errorf("Ref = %q, want main", repo.Ref)
+	}
+	if len(repo.Badges) != 1 || repo.Badges[0].HostImage != "img.shields.io" {
+		t.Errorf("Badges = %+v", repo.Badges)
+	}
+	if len(r.migrated) != 1 {
+		t.Errorf("migrated = %v, want one migration", r.migrated)
+	}
+
+	want := []string{
+		`alpha.json: ignoring unknown field "badges[0].color"`,
+		`alpha.json: ignoring unknown field "stars"`,
+	}
+	if !reflect.DeepEqual(r.warnings, want) {
+		t.Errorf("warnings = %q, want %q", r.warnings, want)
+	}
+}
+
+func TestDecodeRecordCurrentVersion(t *testing.T) {
+	t.Parallel()
+
+	current := `{"schema_version": 2, "repository": "alpha", "default_branch": "main", "ref": "release", "readme_found": false, "badges": null}`
+
+	r := &report{}
+	repo, err := decodeRecord[models.RepositoryData]([]byte(current), "alpha.json", repositoryMigrations, r)
+	if err != nil {
+		t.Fatalf("decodeRecord() error = %v", err)
+	}
+	if repo.Ref != "release" {
+		t.Errorf("Ref = %q, want release", repo.Ref)
+	}
+	if len(r.migrated) != 0 || len(r.warnings) != 0 {
+		t.Errorf("unexpected notices: migrated %v, warnings %v", r.migrated, r.warnings)
+	}
+}
+
+func TestDecodeRecordNewerVersion(t *testing.T) {
+	t.Parallel()
+
+	newer := `{"schema_version": 99, "repository": "alpha", "readme_found": true, "badges": []}`
+
+	r := &report{}
+	repo, err := decodeRecord[models.RepositoryData]([]byte(newer), "alpha.json", repositoryMigrations, r)
+	if err != nil {
+		t.Fatalf("decodeRecord() error = %v", err)
+	}
+	if repo.Repository != "alpha" {
+		t.Errorf("Repository = %q, want alpha", repo.Repository)
+	}
+	if len(r.warnings) != 1 || !strings.Contains(r.warnings[0], "newer than supported") {
+		t.Errorf("warnings = %q, want a newer version warning", r.warnings)
+	}
+}
+
+// TestRepositorySchemaMatchesModels keeps the published JSON Schema in step
+// with the Go types.
+func TestRepositorySchemaMatchesModels(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile("../../schema/repository.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type object struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	var schema struct {
+		object
+		Defs struct {
+			Badge object `json:"badge"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("parse schema: %v", err)
+	}
+
+	check := func(name string, properties map[string]json.RawMessage, typ reflect.Type) {
+		var got, want []string
+		for key := range properties {
+			got = append(got, key)
+		}
+		for key := range jsonFields(typ) {
+			want = append(want, key)
+		}
+		sort.Strings(got)
+		sort.Strings(want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s schema properties = %v, want %v", name, got, want)
+		}
+	}
+	check("repository", schema.Properties, reflect.TypeFor[models.RepositoryData]())
+	check("badge", schema.Defs.Badge.Properties, reflect.TypeFor[models.Badge]())
+}
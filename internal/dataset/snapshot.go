@@ -0,0 +1,94 @@
+package dataset
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+)
+
+// Snapshots are gzipped JSONL bundles named by crawl time, so that a plain
+// directory listing sorts them chronologically.
+const (
+	snapshotTimeFormat = "20060102T150405Z"
+	snapshotExt        = ".jsonl.gz"
+)
+
+// WriteSnapshot archives the crawl as a timestamped bundle in dir and returns
+// the snapshot path.
+func WriteSnapshot(dir string, bundle *models.Bundle) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	path := filepath.Join(dir, bundle.CrawledAt.UTC().Format(snapshotTimeFormat)+snapshotExt)
+	return path, WriteBundle(path, bundle)
+}
+
+// ListSnapshots returns the snapshot paths in dir, oldest first. A missing
+// directory has no snapshots.
+func ListSnapshots(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, snapshotExt) {
+			continue
+		}
+		if _, err := time.Parse(snapshotTimeFormat, strings.TrimSuffix(name, snapshotExt)); err != nil {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, name))
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// LoadHistory reads every archived crawl, oldest first: the runs of the
+// SQLite store when src names one, plus any snapshots in snapshotDir.
+func LoadHistory(src Source, snapshotDir string) ([]*models.Bundle, error) {
+	var history []*models.Bundle
+
+	if src.SQLite != "" {
+		runs, err := ListSQLiteRuns(src.SQLite)
+		if err != nil {
+			return nil, err
+		}
+		for _, run := range runs {
+			bundle, err := ReadSQLiteRun(src.SQLite, run.ID)
+			if err != nil {
+				return nil, err
+			}
+			history = append(history, bundle)
+		}
+	}
+
+	if snapshotDir != "" {
+		paths, err := ListSnapshots(snapshotDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			bundle, err := ReadBundle(path)
+			if err != nil {
+				return nil, err
+			}
+			history = append(history, bundle)
+		}
+	}
+
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].CrawledAt.Before(history[j].CrawledAt)
+	})
+	return history, nil
+}
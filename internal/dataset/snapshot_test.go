@@ -0,0 +1,68 @@
+package dataset
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadHistory(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	snapshots := filepath.Join(dir, "snapshots")
+	dbPath := filepath.Join(dir, "history.db")
+
+	stored := sampleBundle()
+	stored.CrawledAt = time.Date(2026, 9, 15, 0, 0, 0, 0, time.UTC)
+	if _, err := WriteSQLite(dbPath, stored); err != nil {
+		t.Fatalf("WriteSQLite() error = %v", err)
+	}
+
+	times := []time.Time{
+		time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+		time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC),
+	}
+	for _, at := range times {
+		bundle := sampleBundle()
+		bundle.CrawledAt = at
+		path, err := WriteSnapshot(snapshots, bundle)
+		if err != nil {
+			t.Fatalf("WriteSnapshot() error = %v", err)
+		}
+		if want := at.Format(snapshotTimeFormat) + snapshotExt; filepath.Base(path) != want {
+			t.Errorf("WriteSnapshot() path = %s, want %s", filepath.Base(path), want)
+		}
+	}
+	// Files that are not snapshots are ignored.
+	if err := os.WriteFile(filepath.Join(snapshots, "notes.jsonl.gz"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := LoadHistory(Source{SQLite: dbPath}, snapshots)
+	if err != nil {
+		t.Fatalf("LoadHistory() error = %v", err)
+	}
+	want := []time.Time{times[1], stored.CrawledAt, times[0]}
+	if len(history) != len(want) {
+		t.Fatalf("LoadHistory() returned %d crawls, want %d", len(history), len(want))
+	}
+	for i, bundle := range history {
+		if !bundle.CrawledAt.Equal(want[i]) {
+			t.Errorf("history[%d].CrawledAt = %v, want %v", i, bundle.CrawledAt, want[i])
+		}
+	}
+}
+
+func TestLoadHistoryMissingSnapshotDir(t *testing.T) {
+	t.Parallel()
+
+	history, err := LoadHistory(Source{}, filepath.Join(t.TempDir(), "missing"))
+	if err != nil {
+		t.Fatalf("LoadHistory() error = %v", err)
+	}
+	if len(history) != 0 {
+		t.Errorf("LoadHistory() = %d crawls, want none", len(history))
+	}
+}
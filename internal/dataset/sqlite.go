@@ -0,0 +1,372 @@
+package dataset
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+
+	// Pure-Go SQLite driver, registered as "sqlite".
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchemaVersion is stored in PRAGMA user_version and identifies the
+// table layout, independent of the crawl data schema version.
+const sqliteSchemaVersion = 1
+
+// sqliteSchema creates the crawl history tables. Every crawl is a run; the
+// repositories and badges seen in that run reference it. The columns hold the
+// commonly queried fields while data holds the full JSON record, so records
+// round-trip and migrate like the file formats.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS crawl_runs (
+	id             INTEGER PRIMARY KEY AUTOINCREMENT,
+	organization   TEXT    NOT NULL,
+	crawled_at     TEXT    NOT NULL,
+	schema_version INTEGER NOT NULL,
+	config         TEXT    NOT NULL,
+	profile        TEXT
+);
+
+CREATE TABLE IF NOT EXISTS repositories (
+	run_id         INTEGER NOT NULL REFERENCES crawl_runs(id) ON DELETE CASCADE,
+	name           TEXT    NOT NULL,
+	url            TEXT    NOT NULL,
+	default_branch TEXT    NOT NULL,
+	ref            TEXT    NOT NULL,
+	readme_found   INTEGER NOT NULL,
+	data           TEXT    NOT NULL,
+	PRIMARY KEY (run_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS badges (
+	run_id      INTEGER NOT NULL,
+	repository  TEXT    NOT NULL,
+	position    INTEGER NOT NULL,
+	source      TEXT    NOT NULL,
+	alt_text    TEXT    NOT NULL,
+	image_url   TEXT    NOT NULL,
+	target_url  TEXT    NOT NULL,
+	host_image  TEXT    NOT NULL,
+	host_target TEXT    NOT NULL,
+	data        TEXT    NOT NULL,
+	PRIMARY KEY (run_id, repository, position),
+	FOREIGN KEY (run_id, repository) REFERENCES repositories(run_id, name) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS badges_image_url ON badges(image_url);
+CREATE INDEX IF NOT EXISTS repositories_name ON repositories(name);
+`
+
+// Run is a crawl recorded in a SQLite store.
+type Run struct {
+	ID           int64
+	Organization string
+	CrawledAt    time.Time
+}
+
+// openExistingSQLite opens a store for reading without creating it.
+func openExistingSQLite(path string) (*sql.DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	return openSQLite(path)
+}
+
+// openSQLite opens the store, creating or checking its tables.
+func openSQLite(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	switch {
+	case version == 0:
+		if _, err := db.Exec(sqliteSchema); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to create tables in %s: %w", path, err)
+		}
+		if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", sqliteSchemaVersion)); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to initialize %s: %w", path, err)
+		}
+	case version > sqliteSchemaVersion:
+		db.Close()
+		return nil, fmt.Errorf("%s uses store version %d, newer than supported version %d", path, version, sqliteSchemaVersion)
+	}
+	return db, nil
+}
+
+// WriteSQLite records the crawl as a new run in the SQLite store at path,
+// creating the database when needed, and returns the run ID.
+func WriteSQLite(path string, bundle *models.Bundle) (int64, error) {
+	stampSchemaVersion(bundle)
+
+	db, err := openSQLite(path)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	config, err := json.Marshal(bundle.Config)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode crawl config: %w", err)
+	}
+	var profile sql.NullString
+	if bundle.Profile != nil {
+		data, err := json.Marshal(bundle.Profile)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode organization profile: %w", err)
+		}
+		profile = sql.NullString{String: string(data), Valid: true}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT INTO crawl_runs (organization, crawled_at, schema_version, config, profile) VALUES (?, ?, ?, ?, ?)`,
+		bundle.Organization, bundle.CrawledAt.UTC().Format(time.RFC3339Nano), bundle.SchemaVersion, string(config), profile)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record crawl run: %w", err)
+	}
+	runID, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to record crawl run: %w", err)
+	}
+
+	repoStmt, err := tx.Prepare(`INSERT INTO repositories (run_id, name, url, default_branch, ref, readme_found, data) VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, err
+	}
+	defer repoStmt.Close()
+	badgeStmt, err := tx.Prepare(`INSERT INTO badges (run_id, repository, position, source, alt_text, image_url, target_url, host_image, host_target, data) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, err
+	}
+	defer badgeStmt.Close()
+
+	for _, repo := range bundle.Repositories {
+		badges := repo.Badges
+		repo.Badges = nil
+		data, err := json.Marshal(repo)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode %s: %w", repo.Repository, err)
+		}
+		if _, err := repoStmt.Exec(runID, repo.Repository, repo.RepositoryURL, repo.DefaultBranch, repo.Ref, repo.ReadmeFound, string(data)); err != nil {
+			return 0, fmt.Errorf("failed to record %s: %w", repo.Repository, err)
+		}
+		for i, b := range badges {
+			data, err := json.Marshal(b)
+			if err != nil {
+				return 0, fmt.Errorf("failed to encode badge for %s: %w", repo.Repository, err)
+			}
+			if _, err := badgeStmt.Exec(runID, repo.Repository, i, b.Source, b.AltText, b.ImageURL, b.TargetURL, b.HostImage, b.HostTarget, string(data)); err != nil {
+				return 0, fmt.Errorf("failed to record badge for %s: %w", repo.Repository, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit crawl run: %w", err)
+	}
+	return runID, nil
+}
+
+// UpdateSQLiteBadges replaces the badge records and organization profile of
+// a recorded run, or of the most recent run when runID is zero, with those of
+// the bundle. The repositories and their badges must be those of the run.
+func UpdateSQLiteBadges(path string, runID int64, bundle *models.Bundle) error {
+	stampSchemaVersion(bundle)
+
+	db, err := openExistingSQLite(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if runID == 0 {
+		err := db.QueryRow(`SELECT id FROM crawl_runs ORDER BY id DESC LIMIT 1`).Scan(&runID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("no crawl runs recorded in %s", path)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read crawl run: %w", err)
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if bundle.Profile != nil {
+		data, err := json.Marshal(bundle.Profile)
+		if err != nil {
+			return fmt.Errorf("failed to encode organization profile: %w", err)
+		}
+		if _, err := tx.Exec(`UPDATE crawl_runs SET profile = ? WHERE id = ?`, string(data), runID); err != nil {
+			return fmt.Errorf("failed to update organization profile: %w", err)
+		}
+	}
+
+	badgeStmt, err := tx.Prepare(`UPDATE badges SET data = ? WHERE run_id = ? AND repository = ? AND position = ?`)
+	if err != nil {
+		return err
+	}
+	defer badgeStmt.Close()
+	for _, repo := range bundle.Repositories {
+		for i, b := range repo.Badges {
+			data, err := json.Marshal(b)
+			if err != nil {
+				return fmt.Errorf("failed to encode badge for %s: %w", repo.Repository, err)
+			}
+			result, err := badgeStmt.Exec(string(data), runID, repo.Repository, i)
+			if err != nil {
+				return fmt.Errorf("failed to update badge for %s: %w", repo.Repository, err)
+			}
+			if n, err := result.RowsAffected(); err == nil && n == 0 {
+				return fmt.Errorf("run %d has no badge %d for %s", runID, i, repo.Repository)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit badge updates: %w", err)
+	}
+	return nil
+}
+
+// ListSQLiteRuns returns every recorded run, oldest first.
+func ListSQLiteRuns(path string) ([]Run, error) {
+	db, err := openExistingSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT id, organization, crawled_at FROM crawl_runs ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list crawl runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []Run
+	for rows.Next() {
+		var run Run
+		var crawledAt string
+		if err := rows.Scan(&run.ID, &run.Organization, &crawledAt); err != nil {
+			return nil, fmt.Errorf("failed to list crawl runs: %w", err)
+		}
+		run.CrawledAt, _ = time.Parse(time.RFC3339Nano, crawledAt)
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// ReadSQLite reads the most recent run from the SQLite store.
+func ReadSQLite(path string) (*models.Bundle, error) {
+	return ReadSQLiteRun(path, 0)
+}
+
+// ReadSQLiteRun reads a recorded run by ID, or the most recent run when runID
+// is zero.
+func ReadSQLiteRun(path string, runID int64) (*models.Bundle, error) {
+	db, err := openExistingSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	query := `SELECT id, organization, crawled_at, schema_version, config, profile FROM crawl_runs WHERE id = ?`
+	args := []any{runID}
+	if runID == 0 {
+		query = `SELECT id, organization, crawled_at, schema_version, config, profile FROM crawl_runs ORDER BY id DESC LIMIT 1`
+		args = nil
+	}
+
+	bundle := &models.Bundle{}
+	var crawledAt, config string
+	var profile sql.NullString
+	err = db.QueryRow(query, args...).Scan(&runID, &bundle.Organization, &crawledAt, &bundle.SchemaVersion, &config, &profile)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("no crawl runs recorded in %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read crawl run: %w", err)
+	}
+	bundle.CrawledAt, _ = time.Parse(time.RFC3339Nano, crawledAt)
+	if err := json.Unmarshal([]byte(config), &bundle.Config); err != nil {
+		return nil, fmt.Errorf("failed to decode crawl config: %w", err)
+	}
+
+	r := &report{}
+	defer r.print()
+
+	if profile.Valid {
+		org, err := decodeRecord[models.OrganizationData]([]byte(profile.String), "profile", nil, r)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Profile = &org
+	}
+
+	// Badges are attached to their repository record before it is decoded, so
+	// the whole record migrates as it would from a file.
+	badges := make(map[string][]any)
+	badgeRows, err := db.Query(`SELECT repository, data FROM badges WHERE run_id = ? ORDER BY repository, position`, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read badges: %w", err)
+	}
+	defer badgeRows.Close()
+	for badgeRows.Next() {
+		var repoName, data string
+		if err := badgeRows.Scan(&repoName, &data); err != nil {
+			return nil, fmt.Errorf("failed to read badges: %w", err)
+		}
+		badge, err := decodeObject([]byte(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode badge for %s: %w", repoName, err)
+		}
+		badges[repoName] = append(badges[repoName], badge)
+	}
+	if err := badgeRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read badges: %w", err)
+	}
+
+	rows, err := db.Query(`SELECT name, data FROM repositories WHERE run_id = ? ORDER BY name`, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read repositories: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, data string
+		if err := rows.Scan(&name, &data); err != nil {
+			return nil, fmt.Errorf("failed to read repositories: %w", err)
+		}
+		record, err := decodeObject([]byte(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", name, err)
+		}
+		record["badges"] = badges[name]
+		repo, err := decodeRecordObject[models.RepositoryData](record, name, repositoryMigrations, r)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Repositories = append(bundle.Repositories, repo)
+	}
+	return bundle, rows.Err()
+}
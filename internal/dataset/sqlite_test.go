@@ -0,0 +1,119 @@
+package dataset
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+)
+
+func TestSQLiteRuns(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "history.db")
+
+	first := sampleBundle()
+	first.CrawledAt = time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	first.Repositories = first.Repositories[:1]
+	firstID, err := WriteSQLite(path, first)
+	if err != nil {
+		t.Fatalf("WriteSQLite() error = %v", err)
+	}
+
+	second := sampleBundle()
+	secondID, err := WriteSQLite(path, second)
+	if err != nil {
+		t.Fatalf("WriteSQLite() error = %v", err)
+	}
+
+	runs, err := ListSQLiteRuns(path)
+	if err != nil {
+		t.Fatalf("ListSQLiteRuns() error = %v", err)
+	}
+	if len(runs) != 2 || runs[0].ID != firstID || runs[1].ID != secondID {
+		t.Fatalf("ListSQLiteRuns() = %+v", runs)
+	}
+	if !runs[0].CrawledAt.Equal(first.CrawledAt) {
+		t.Errorf("first run CrawledAt = %v, want %v", runs[0].CrawledAt, first.CrawledAt)
+	}
+
+	latest, err := ReadSQLite(path)
+	if err != nil {
+		t.Fatalf("ReadSQLite() error = %v", err)
+	}
+	if !latest.CrawledAt.Equal(second.CrawledAt) {
+		t.Errorf("latest CrawledAt = %v, want %v", latest.CrawledAt, second.CrawledAt)
+	}
+	if !reflect.DeepEqual(latest.Repositories, second.Repositories) {
+		t.Errorf("latest Repositories = %+v, want %+v", latest.Repositories, second.Repositories)
+	}
+	if !reflect.DeepEqual(latest.Profile, second.Profile) || !reflect.DeepEqual(latest.Config, second.Config) {
+		t.Errorf("latest run metadata = %+v / %+v", latest.Profile, latest.Config)
+	}
+
+	older, err := ReadSQLiteRun(path, firstID)
+	if err != nil {
+		t.Fatalf("ReadSQLiteRun() error = %v", err)
+	}
+	if len(older.Repositories) != 1 {
+		t.Errorf("first run has %d repositories, want 1", len(older.Repositories))
+	}
+}
+
+func TestReadSQLiteMissingStore(t *testing.T) {
+	t.Parallel()
+
+	if _, err := ReadSQLite(filepath.Join(t.TempDir(), "missing.db")); err == nil {
+		t.Fatal("ReadSQLite() of a missing store succeeded")
+	}
+}
+
+func TestUpdateSQLiteBadges(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "history.db")
+	firstID, err := WriteSQLite(path, sampleBundle())
+	if err != nil {
+		t.Fatalf("WriteSQLite() error = %v", err)
+	}
+	if _, err := WriteSQLite(path, sampleBundle()); err != nil {
+		t.Fatalf("WriteSQLite() error = %v", err)
+	}
+
+	src := Source{SQLite: path, Run: firstID}
+	bundle, err := Load(src)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	status := &models.LinkStatus{StatusCode: 404, ContentType: "text/html", LatencyMS: 12, CheckedAt: time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)}
+	bundle.Repositories[0].Badges[0].ImageStatus = status
+	bundle.Profile.Badges[0].TargetStatus = status
+	if err := Save(src, bundle); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	updated, err := Load(src)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(updated.Repositories, bundle.Repositories) || !reflect.DeepEqual(updated.Profile, bundle.Profile) {
+		t.Errorf("updated run = %+v, want %+v", updated.Repositories, bundle.Repositories)
+	}
+	if runs, _ := ListSQLiteRuns(path); len(runs) != 2 {
+		t.Errorf("Save() recorded a new run: %+v", runs)
+	}
+	latest, err := ReadSQLite(path)
+	if err != nil {
+		t.Fatalf("ReadSQLite() error = %v", err)
+	}
+	if latest.Repositories[0].Badges[0].ImageStatus != nil {
+		t.Error("Save() of the first run changed the latest run")
+	}
+
+	bundle.Repositories[0].Badges = append(bundle.Repositories[0].Badges, models.Badge{ImageURL: "https://example.com/new.svg"})
+	if err := UpdateSQLiteBadges(path, firstID, bundle); err == nil {
+		t.Error("UpdateSQLiteBadges() with a badge missing from the run succeeded")
+	}
+}
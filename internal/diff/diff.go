@@ -0,0 +1,251 @@
+// Package diff compares two crawls and reports the repositories and badges
+// that changed between them.
+package diff
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/catalog"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/dataset"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+)
+
+// Report describes the changes from one crawl to another.
+type Report struct {
+	From         Crawl           `json:"from"`
+	To           Crawl           `json:"to"`
+	ReposAdded   []string        `json:"repos_added,omitempty"`
+	ReposRemoved []string        `json:"repos_removed,omitempty"`
+	Repositories []RepoChange    `json:"repositories,omitempty"`
+	Patterns     []PatternChange `json:"patterns,omitempty"`
+}
+
+// Crawl identifies one side of the comparison. Source is left for the caller
+// to fill in, since Compare only sees the loaded crawls.
+type Crawl struct {
+	Source       string    `json:"source"`
+	Organization string    `json:"organization"`
+	CrawledAt    time.Time `json:"crawled_at"`
+	Repositories int       `json:"repositories"`
+}
+
+// RepoChange lists the badge changes in a repository present in both crawls.
+type RepoChange struct {
+	Repository string        `json:"repository"`
+	Added      []Badge       `json:"added,omitempty"`
+	Removed    []Badge       `json:"removed,omitempty"`
+	Changed    []BadgeChange `json:"changed,omitempty"`
+}
+
+// Badge is a badge occurrence identified against the catalog.
+type Badge struct {
+	Pattern   string `json:"pattern"`
+	Name      string `json:"name"`
+	Category  string `json:"category"`
+	AltText   string `json:"alt_text"`
+	ImageURL  string `json:"image_url"`
+	TargetURL string `json:"target_url"`
+	Source    string `json:"source,omitempty"`
+}
+
+// BadgeChange is a badge whose pattern is unchanged but whose image URL,
+// target, alt text or source file differs.
+type BadgeChange struct {
+	From Badge `json:"from"`
+	To   Badge `json:"to"`
+}
+
+// PatternChange is a badge pattern whose number of repositories changed.
+type PatternChange struct {
+	Pattern  string `json:"pattern"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	From     int    `json:"from"`
+	To       int    `json:"to"`
+}
+
+// Empty reports whether the crawls have no differences.
+func (r *Report) Empty() bool {
+	return len(r.ReposAdded) == 0 && len(r.ReposRemoved) == 0 && len(r.Repositories) == 0 && len(r.Patterns) == 0
+}
+
+// Compare reports the changes from one crawl to another, naming badges with
+// the catalog. Repositories are matched by name, ignoring case, and badges
+// within a repository by canonical pattern.
+func Compare(from, to *models.Bundle, config *catalog.Config) *Report {
+	report := &Report{
+		From: crawlInfo(from),
+		To:   crawlInfo(to),
+	}
+
+	fromRepos := indexRepos(from, config)
+	toRepos := indexRepos(to, config)
+
+	for key, repo := range toRepos {
+		if _, ok := fromRepos[key]; !ok {
+			report.ReposAdded = append(report.ReposAdded, repo.name)
+		}
+	}
+	for key, old := range fromRepos {
+		current, ok := toRepos[key]
+		if !ok {
+			report.ReposRemoved = append(report.ReposRemoved, old.name)
+			continue
+		}
+		if change := compareBadges(current.name, old.badges, current.badges); change != nil {
+			report.Repositories = append(report.Repositories, *change)
+		}
+	}
+	sort.Strings(report.ReposAdded)
+	sort.Strings(report.ReposRemoved)
+	sort.Slice(report.Repositories, func(i, j int) bool {
+		return report.Repositories[i].Repository < report.Repositories[j].Repository
+	})
+
+	report.Patterns = comparePatterns(fromRepos, toRepos, config)
+	return report
+}
+
+func crawlInfo(bundle *models.Bundle) Crawl {
+	return Crawl{
+		Organization: dataset.OrganizationName(bundle),
+		CrawledAt:    bundle.CrawledAt,
+		Repositories: len(bundle.Repositories),
+	}
+}
+
+type indexedRepo struct {
+	name   string
+	badges []Badge
+}
+
+// indexRepos identifies every badge in the crawl, keyed by lowercase
+// repository name.
+func indexRepos(bundle *models.Bundle, config *catalog.Config) map[string]indexedRepo {
+	orgName := dataset.OrganizationName(bundle)
+	repos := make(map[string]indexedRepo, len(bundle.Repositories))
+	for _, repo := range bundle.Repositories {
+		indexed := indexedRepo{name: repo.Repository}
+		for _, b := range repo.Badges {
+			pattern := catalog.Canonicalize(b.ImageURL, orgName, repo.Repository)
+			name, category, _, _ := config.Lookup(pattern)
+			indexed.badges = append(indexed.badges, Badge{
+				Pattern:   pattern,
+				Name:      name,
+				Category:  category,
+				AltText:   b.AltText,
+				ImageURL:  b.ImageURL,
+				TargetURL: b.TargetURL,
+				Source:    b.Source,
+			})
+		}
+		repos[strings.ToLower(repo.Repository)] = indexed
+	}
+	return repos
+}
+
+// compareBadges pairs the badges of each pattern in order; unpaired badges
+// were added or removed. It returns nil when nothing changed.
+func compareBadges(repoName string, from, to []Badge) *RepoChange {
+	fromByPattern := groupByPattern(from)
+	toByPattern := groupByPattern(to)
+
+	change := RepoChange{Repository: repoName}
+	for _, b := range to {
+		if len(fromByPattern[b.Pattern]) == 0 {
+			change.Added = append(change.Added, b)
+			continue
+		}
+		old := fromByPattern[b.Pattern][0]
+		fromByPattern[b.Pattern] = fromByPattern[b.Pattern][1:]
+		if old.ImageURL != b.ImageURL || old.TargetURL != b.TargetURL || old.AltText != b.AltText || old.Source != b.Source {
+			change.Changed = append(change.Changed, BadgeChange{From: old, To: b})
+		}
+	}
+	for _, b := range from {
+		if len(toByPattern[b.Pattern]) == 0 {
+			change.Removed = append(change.Removed, b)
+			continue
+		}
+		toByPattern[b.Pattern] = toByPattern[b.Pattern][1:]
+	}
+
+	if len(change.Added) == 0 && len(change.Removed) == 0 && len(change.Changed) == 0 {
+		return nil
+	}
+	return &change
+}
+
+func groupByPattern(badges []Badge) map[string][]Badge {
+	grouped := make(map[string][]Badge)
+	for _, b := range badges {
+		grouped[b.Pattern] = append(grouped[b.Pattern], b)
+	}
+	return grouped
+}
+
+// comparePatterns counts the repositories using each pattern in both crawls
+// and returns the patterns whose count changed, largest change first.
+func comparePatterns(from, to map[string]indexedRepo, config *catalog.Config) []PatternChange {
+	fromCounts := countPatterns(from)
+	toCounts := countPatterns(to)
+
+	var changes []PatternChange
+	for pattern := range mergeKeys(fromCounts, toCounts) {
+		if fromCounts[pattern] == toCounts[pattern] {
+			continue
+		}
+		name, category, _, _ := config.Lookup(pattern)
+		changes = append(changes, PatternChange{
+			Pattern:  pattern,
+			Name:     name,
+			Category: category,
+			From:     fromCounts[pattern],
+			To:       toCounts[pattern],
+		})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		di, dj := abs(changes[i].To-changes[i].From), abs(changes[j].To-changes[j].From)
+		if di != dj {
+			return di > dj
+		}
+		return changes[i].Pattern < changes[j].Pattern
+	})
+	return changes
+}
+
+// countPatterns counts each repository once per pattern.
+func countPatterns(repos map[string]indexedRepo) map[string]int {
+	counts := make(map[string]int)
+	for _, repo := range repos {
+		seen := make(map[string]struct{})
+		for _, b := range repo.badges {
+			if _, ok := seen[b.Pattern]; ok {
+				continue
+			}
+			seen[b.Pattern] = struct{}{}
+			counts[b.Pattern]++
+		}
+	}
+	return counts
+}
+
+func mergeKeys(a, b map[string]int) map[string]struct{} {
+	keys := make(map[string]struct{}, len(a)+len(b))
+	for k := range a {
+		keys[k] = struct{}{}
+	}
+	for k := range b {
+		keys[k] = struct{}{}
+	}
+	return keys
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
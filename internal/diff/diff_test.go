@@ -0,0 +1,171 @@
+package diff
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/catalog"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+)
+
+func testCatalog() *catalog.Config {
+	return &catalog.Config{Badges: []catalog.Entry{
+		{ID: "license", Pattern: "https://img.shields.io/github/license/{ORG}/{REPO}/.*", Name: "License", Category: "Meta"},
+		{ID: "travis", Pattern: "https://travis-ci.org/{ORG}/{REPO}/.*", Name: "Travis CI", Category: "Build"},
+	}}
+}
+
+func repo(name string, badges ...models.Badge) models.RepositoryData {
+	return models.RepositoryData{
+		Repository:    name,
+		RepositoryURL: "https://github.com/example/" + name,
+		ReadmeFound:   true,
+		Badges:        badges,
+	}
+}
+
+func license(repoName string) models.Badge {
+	return models.Badge{
+		AltText:  "License",
+		ImageURL: "https://img.shields.io/github/license/example/" + repoName,
+		Source:   "README.md",
+	}
+}
+
+func travis(repoName string) models.Badge {
+	return models.Badge{
+		AltText:  "Build",
+		ImageURL: "https://travis-ci.org/example/" + repoName + ".svg",
+		Source:   "README.md",
+	}
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	moved := license("beta")
+	moved.Source = "docs/index.md"
+
+	from := &models.Bundle{
+		Organization: "example",
+		CrawledAt:    time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC),
+		Repositories: []models.RepositoryData{
+			repo("alpha", license("alpha"), travis("alpha")),
+			repo("beta", license("beta")),
+			repo("legacy", travis("legacy")),
+		},
+	}
+	to := &models.Bundle{
+		Organization: "example",
+		CrawledAt:    time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		Repositories: []models.RepositoryData{
+			repo("Alpha", license("Alpha")),
+			repo("beta", moved),
+			repo("gamma", license("gamma")),
+		},
+	}
+
+	report := Compare(from, to, testCatalog())
+
+	if got := strings.Join(report.ReposAdded, ","); got != "gamma" {
+		t.Errorf("ReposAdded = %q, want gamma", got)
+	}
+	if got := strings.Join(report.ReposRemoved, ","); got != "legacy" {
+		t.Errorf("ReposRemoved = %q, want legacy", got)
+	}
+
+	if len(report.Repositories) != 2 {
+		t.Fatalf("Repositories = %+v, want changes for Alpha and beta", report.Repositories)
+	}
+	alpha := report.Repositories[0]
+	if alpha.Repository != "Alpha" || len(alpha.Added) != 0 || len(alpha.Removed) != 1 || alpha.Removed[0].Name != "Travis CI" {
+		t.Errorf("Alpha changes = %+v, want the Travis CI badge removed", alpha)
+	}
+	// The renamed repository keeps its license pattern but changes its URL.
+	if len(alpha.Changed) != 1 {
+		t.Errorf("Alpha changed = %+v, want the license URL change", alpha.Changed)
+	}
+	beta := report.Repositories[1]
+	if len(beta.Changed) != 1 || beta.Changed[0].To.Source != "docs/index.md" {
+		t.Errorf("beta changes = %+v, want the license moved to docs/index.md", beta)
+	}
+
+	want := map[string][2]int{
+		"License":   {2, 3},
+		"Travis CI": {2, 0},
+	}
+	if len(report.Patterns) != len(want) {
+		t.Fatalf("Patterns = %+v, want %d changes", report.Patterns, len(want))
+	}
+	for _, p := range report.Patterns {
+		if counts, ok := want[p.Name]; !ok || counts != [2]int{p.From, p.To} {
+			t.Errorf("pattern %s: %d -> %d, want %v", p.Name, p.From, p.To, counts)
+		}
+	}
+	if report.Patterns[0].Name != "Travis CI" {
+		t.Errorf("Patterns[0] = %s, want the largest change first", report.Patterns[0].Name)
+	}
+}
+
+func TestCompareIdentical(t *testing.T) {
+	t.Parallel()
+
+	bundle := &models.Bundle{
+		Organization: "example",
+		Repositories: []models.RepositoryData{repo("alpha", license("alpha"))},
+	}
+	report := Compare(bundle, bundle, testCatalog())
+	if !report.Empty() {
+		t.Fatalf("Compare() of identical crawls = %+v, want no changes", report)
+	}
+
+	var out bytes.Buffer
+	if err := Write(&out, report, FormatText); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "No changes.") {
+		t.Errorf("text output = %q, want a no changes note", out.String())
+	}
+}
+
+func TestWriteFormats(t *testing.T) {
+	t.Parallel()
+
+	report := &Report{
+		From:         Crawl{Source: "old", Repositories: 1},
+		To:           Crawl{Source: "new", Repositories: 2},
+		ReposAdded:   []string{"gamma"},
+		Repositories: []RepoChange{{Repository: "alpha", Added: []Badge{{Pattern: "https://a|b", Name: catalog.Unknown, ImageURL: "https://a|b"}}}},
+		Patterns:     []PatternChange{{Pattern: "https://a|b", Name: catalog.Unknown, From: 0, To: 1}},
+	}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{FormatText, []string{"  + gamma", "    + https://a|b", "https://a|b: 0 -> 1 (+1)"}},
+		{FormatMarkdown, []string{"- `gamma`", "| alpha | Added | https://a\\|b |", "| https://a\\|b | 0 | 1 | +1 |"}},
+		{FormatJSON, []string{`"repos_added": [`, `"pattern": "https://a|b"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			if err := Write(&out, report, tt.format); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("%s output missing %q:\n%s", tt.format, want, out.String())
+				}
+			}
+		})
+	}
+
+	if err := Write(&bytes.Buffer{}, report, "yaml"); err == nil {
+		t.Error("Write() with an unknown format succeeded")
+	}
+}
@@ -0,0 +1,187 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/catalog"
+)
+
+// Output formats.
+const (
+	FormatText     = "text"
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+)
+
+// Write renders the report in the given format.
+func Write(w io.Writer, r *Report, format string) error {
+	switch format {
+	case FormatText:
+		return writeText(w, r)
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	case FormatMarkdown:
+		return writeMarkdown(w, r)
+	default:
+		return fmt.Errorf("unknown diff format %q (expected %s, %s or %s)", format, FormatText, FormatJSON, FormatMarkdown)
+	}
+}
+
+func writeText(w io.Writer, r *Report) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Comparing %s (%s) to %s (%s)\n", r.From.Source, crawlTime(r.From.CrawledAt), r.To.Source, crawlTime(r.To.CrawledAt))
+	fmt.Fprintf(&b, "Repositories: %d -> %d (%d added, %d removed)\n", r.From.Repositories, r.To.Repositories, len(r.ReposAdded), len(r.ReposRemoved))
+	if r.Empty() {
+		b.WriteString("\nNo changes.\n")
+	}
+
+	if len(r.ReposAdded) > 0 {
+		b.WriteString("\nAdded repositories:\n")
+		for _, name := range r.ReposAdded {
+			fmt.Fprintf(&b, "  + %s\n", name)
+		}
+	}
+	if len(r.ReposRemoved) > 0 {
+		b.WriteString("\nRemoved repositories:\n")
+		for _, name := range r.ReposRemoved {
+			fmt.Fprintf(&b, "  - %s\n", name)
+		}
+	}
+
+	if len(r.Repositories) > 0 {
+		b.WriteString("\nBadge changes:\n")
+		for _, repo := range r.Repositories {
+			fmt.Fprintf(&b, "  %s\n", repo.Repository)
+			for _, badge := range repo.Added {
+				fmt.Fprintf(&b, "    + %s\n", badgeDescription(badge))
+			}
+			for _, badge := range repo.Removed {
+				fmt.Fprintf(&b, "    - %s\n", badgeDescription(badge))
+			}
+			for _, change := range repo.Changed {
+				fmt.Fprintf(&b, "    ~ %s: %s\n", badgeLabel(change.To), strings.Join(changeDetails(change), ", "))
+			}
+		}
+	}
+
+	if len(r.Patterns) > 0 {
+		b.WriteString("\nBadge adoption:\n")
+		for _, p := range r.Patterns {
+			fmt.Fprintf(&b, "  %s: %d -> %d (%+d)\n", patternLabel(p), p.From, p.To, p.To-p.From)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMarkdown(w io.Writer, r *Report) error {
+	var b strings.Builder
+	b.WriteString("## Badge Changes\n\n")
+	fmt.Fprintf(&b, "Comparing `%s` (%s) to `%s` (%s).\n\n", r.From.Source, crawlTime(r.From.CrawledAt), r.To.Source, crawlTime(r.To.CrawledAt))
+	fmt.Fprintf(&b, "Repositories: %d → %d (%d added, %d removed)\n", r.From.Repositories, r.To.Repositories, len(r.ReposAdded), len(r.ReposRemoved))
+	if r.Empty() {
+		b.WriteString("\nNo changes.\n")
+	}
+
+	if len(r.ReposAdded) > 0 {
+		b.WriteString("\n### Added Repositories\n\n")
+		for _, name := range r.ReposAdded {
+			fmt.Fprintf(&b, "- `%s`\n", name)
+		}
+	}
+	if len(r.ReposRemoved) > 0 {
+		b.WriteString("\n### Removed Repositories\n\n")
+		for _, name := range r.ReposRemoved {
+			fmt.Fprintf(&b, "- `%s`\n", name)
+		}
+	}
+
+	if len(r.Repositories) > 0 {
+		b.WriteString("\n### Badge Changes by Repository\n\n")
+		b.WriteString("| Repository | Change | Badge | Details |\n")
+		b.WriteString("| --- | --- | --- | --- |\n")
+		for _, repo := range r.Repositories {
+			for _, badge := range repo.Added {
+				fmt.Fprintf(&b, "| %s | Added | %s | %s |\n", markdownCell(repo.Repository), markdownCell(badgeLabel(badge)), markdownCell(badge.ImageURL))
+			}
+			for _, badge := range repo.Removed {
+				fmt.Fprintf(&b, "| %s | Removed | %s | %s |\n", markdownCell(repo.Repository), markdownCell(badgeLabel(badge)), markdownCell(badge.ImageURL))
+			}
+			for _, change := range repo.Changed {
+				fmt.Fprintf(&b, "| %s | Changed | %s | %s |\n", markdownCell(repo.Repository), markdownCell(badgeLabel(change.To)), markdownCell(strings.Join(changeDetails(change), ", ")))
+			}
+		}
+	}
+
+	if len(r.Patterns) > 0 {
+		b.WriteString("\n### Badge Adoption\n\n")
+		b.WriteString("| Badge | Before | After | Change |\n")
+		b.WriteString("| --- | ---: | ---: | ---: |\n")
+		for _, p := range r.Patterns {
+			fmt.Fprintf(&b, "| %s | %d | %d | %+d |\n", markdownCell(patternLabel(p)), p.From, p.To, p.To-p.From)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// changeDetails describes what differs between two versions of a badge.
+func changeDetails(change BadgeChange) []string {
+	var details []string
+	if change.From.ImageURL != change.To.ImageURL {
+		details = append(details, fmt.Sprintf("image %s -> %s", change.From.ImageURL, change.To.ImageURL))
+	}
+	if change.From.TargetURL != change.To.TargetURL {
+		details = append(details, fmt.Sprintf("target %s -> %s", change.From.TargetURL, change.To.TargetURL))
+	}
+	if change.From.AltText != change.To.AltText {
+		details = append(details, fmt.Sprintf("alt text %q -> %q", change.From.AltText, change.To.AltText))
+	}
+	if change.From.Source != change.To.Source {
+		details = append(details, fmt.Sprintf("moved from %s to %s", change.From.Source, change.To.Source))
+	}
+	return details
+}
+
+// badgeDescription labels a badge and, for catalog badges, shows its image.
+func badgeDescription(b Badge) string {
+	if label := badgeLabel(b); label != b.ImageURL {
+		return fmt.Sprintf("%s (%s)", label, b.ImageURL)
+	}
+	return b.ImageURL
+}
+
+// badgeLabel names a badge by its catalog name, or by its pattern when the
+// catalog does not know it.
+func badgeLabel(b Badge) string {
+	if b.Name == catalog.Unknown {
+		return b.Pattern
+	}
+	return b.Name
+}
+
+func patternLabel(p PatternChange) string {
+	if p.Name == catalog.Unknown {
+		return p.Pattern
+	}
+	return p.Name
+}
+
+func markdownCell(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "|", `\|`), "\n", " ")
+}
+
+func crawlTime(t time.Time) string {
+	if t.IsZero() {
+		return "unknown time"
+	}
+	return t.UTC().Format("January 2, 2006 15:04 MST")
+}
@@ -0,0 +1,44 @@
+// Package fetch holds what the phases that request badge URLs share: how
+// they identify themselves, their default limits and their worker pool.
+package fetch
+
+import (
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Defaults for unset request limits.
+const (
+	DefaultConcurrency = 8
+	DefaultTimeout     = 10 * time.Second
+)
+
+// UserAgent identifies badgeindexer to badge services.
+const UserAgent = "badgeindexer (+https://github.com/UnitVectorY-Labs/badgeindexer)"
+
+// Fetchable reports whether a URL can be requested as written.
+func Fetchable(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Each calls fn for every URL from a fixed pool of at most concurrency
+// workers, which bounds the requests in flight, and returns once all calls
+// have returned. fn must be safe for concurrent use.
+func Each(urls []string, concurrency int, fn func(u string)) {
+	var wg sync.WaitGroup
+	jobs := make(chan string)
+	for range min(concurrency, len(urls)) {
+		wg.Go(func() {
+			for u := range jobs {
+				fn(u)
+			}
+		})
+	}
+	for _, u := range urls {
+		jobs <- u
+	}
+	close(jobs)
+	wg.Wait()
+}
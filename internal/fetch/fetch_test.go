@@ -0,0 +1,60 @@
+package fetch
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFetchable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		raw  string
+		want bool
+	}{
+		{raw: "https://img.shields.io/badge/a-b-c", want: true},
+		{raw: "http://example.com", want: true},
+		{raw: "docs/badge.svg", want: false},
+		{raw: "//example.com/badge.svg", want: false},
+		{raw: "data:image/svg+xml;base64,PHN2Zy8+", want: false},
+		{raw: "https://", want: false},
+		{raw: "https://exa mple.com/%zz", want: false},
+	}
+	for _, tt := range tests {
+		if got := Fetchable(tt.raw); got != tt.want {
+			t.Errorf("Fetchable(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestEach(t *testing.T) {
+	t.Parallel()
+
+	urls := []string{"a", "b", "c", "d", "e", "f", "g"}
+	var mu sync.Mutex
+	var seen []string
+	var inFlight, peak atomic.Int32
+	Each(urls, 3, func(u string) {
+		n := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		mu.Lock()
+		seen = append(seen, u)
+		mu.Unlock()
+		inFlight.Add(-1)
+	})
+
+	slices.Sort(seen)
+	if !slices.Equal(seen, urls) {
+		t.Errorf("Each() visited %v, want %v", seen, urls)
+	}
+	if peak.Load() > 3 {
+		t.Errorf("Each() ran %d calls at once, want at most 3", peak.Load())
+	}
+}
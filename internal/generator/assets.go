@@ -0,0 +1,77 @@
+package generator
+
+import (
+	"embed"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// htmx is vendored as templates/htmx.min.js; the integrity hash pins the
+// exact release, and the tests check the vendored copy against it.
+const (
+	htmxFile      = "htmx.min.js"
+	htmxIntegrity = "sha384-0895/pl2MU10Hqc6jd4RvrthNlDiE9U1tWmX7WRESftEDRosgxNsQG/Ze9YMRzHq"
+)
+
+// assets are the files copied from the templates into the output root.
+var assets = []string{"style.css", "dashboard.js", htmxFile}
+
+// buildSite collects what every page needs to link to the rest of the site
+// and the Content-Security-Policy pages declare.
+func buildSite(basePath string, csp, hotlinkedImages bool) *Site {
+	site := &Site{BasePath: basePath, HTMXURL: basePath + "/" + htmxFile, HTMXIntegrity: htmxIntegrity}
+	if csp {
+		site.CSP = contentSecurityPolicy(hotlinkedImages)
+	}
+	return site
+}
+
+// contentSecurityPolicy allows the pages only their own scripts, styles and
+// snippets, and images from the site and data URLs, adding the badge
+// services only when pages still load images from them.
+func contentSecurityPolicy(hotlinkedImages bool) string {
+	images := "'self' data:"
+	if hotlinkedImages {
+		images += " https:"
+	}
+	return strings.Join([]string{
+		"default-src 'none'",
+		"script-src 'self'",
+		"style-src 'self'",
+		"img-src " + images,
+		"connect-src 'self'",
+		"base-uri 'none'",
+		"form-action 'none'",
+	}, "; ")
+}
+
+// normalizeBasePath turns the URL or path a site is served under into the
+// prefix of its internal links: a path with a leading slash and no trailing
+// one, empty for a domain root.
+func normalizeBasePath(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", fmt.Errorf("invalid base path %q: %w", raw, err)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("invalid base path %q: must not have a query or fragment", raw)
+	}
+	p := path.Clean("/" + u.Path)
+	if p == "/" {
+		return "", nil
+	}
+	return (&url.URL{Path: p}).EscapedPath(), nil
+}
+
+// copyAssets copies the stylesheet and scripts into the output root.
+func copyAssets(templateFS embed.FS, outputDir string) error {
+	for _, name := range assets {
+		if err := copyEmbeddedFile(templateFS, "templates/"+name, filepath.Join(outputDir, name)); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", name, err)
+		}
+	}
+	return nil
+}
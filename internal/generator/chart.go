@@ -0,0 +1,92 @@
+package generator
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"strings"
+	"time"
+)
+
+// Trend chart geometry in SVG user units; the chart scales to its container.
+const (
+	chartWidth   = 640
+	chartHeight  = 180
+	chartPadLeft = 40
+	chartPadTop  = 12
+	chartPadEnd  = 16
+	chartPadBot  = 28
+)
+
+// chartSeries is one line on a trend chart.
+type chartSeries struct {
+	Name   string
+	Values []int
+}
+
+// renderTrendChart draws the series over the given times as an inline SVG line
+// chart. Points carry <title> elements so values show on hover without any
+// script. At least two points are needed to show a trend.
+func renderTrendChart(times []time.Time, series []chartSeries) template.HTML {
+	if len(times) < 2 || len(series) == 0 {
+		return ""
+	}
+
+	maxValue := 1
+	for _, s := range series {
+		for _, v := range s.Values {
+			maxValue = max(maxValue, v)
+		}
+	}
+
+	plotWidth := float64(chartWidth - chartPadLeft - chartPadEnd)
+	plotHeight := float64(chartHeight - chartPadTop - chartPadBot)
+	start, end := times[0], times[len(times)-1]
+	span := end.Sub(start).Seconds()
+
+	x := func(i int) float64 {
+		if span <= 0 {
+			return chartPadLeft + plotWidth*float64(i)/float64(len(times)-1)
+		}
+		return chartPadLeft + plotWidth*times[i].Sub(start).Seconds()/span
+	}
+	y := func(v int) float64 {
+		return chartPadTop + plotHeight*(1-float64(v)/float64(maxValue))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="trend-chart" viewBox="0 0 %d %d" role="img" aria-label="Trend chart">`, chartWidth, chartHeight)
+
+	// Axes and labels
+	bottom := chartHeight - chartPadBot
+	fmt.Fprintf(&b, `<line class="axis" x1="%d" y1="%d" x2="%d" y2="%d"/>`, chartPadLeft, chartPadTop, chartPadLeft, bottom)
+	fmt.Fprintf(&b, `<line class="axis" x1="%d" y1="%d" x2="%d" y2="%d"/>`, chartPadLeft, bottom, chartWidth-chartPadEnd, bottom)
+	fmt.Fprintf(&b, `<text class="axis-label" x="%d" y="%.1f" text-anchor="end">%d</text>`, chartPadLeft-6, y(maxValue)+4, maxValue)
+	fmt.Fprintf(&b, `<text class="axis-label" x="%d" y="%d" text-anchor="end">0</text>`, chartPadLeft-6, bottom+4)
+	fmt.Fprintf(&b, `<text class="axis-label" x="%d" y="%d" text-anchor="start">%s</text>`, chartPadLeft, chartHeight-8, start.UTC().Format("Jan 2, 2006"))
+	fmt.Fprintf(&b, `<text class="axis-label" x="%d" y="%d" text-anchor="end">%s</text>`, chartWidth-chartPadEnd, chartHeight-8, end.UTC().Format("Jan 2, 2006"))
+
+	for i, s := range series {
+		points := make([]string, len(s.Values))
+		for j, v := range s.Values {
+			points[j] = fmt.Sprintf("%.1f,%.1f", x(j), y(v))
+		}
+		fmt.Fprintf(&b, `<polyline class="series series-%d" points="%s"/>`, i, strings.Join(points, " "))
+		for j, v := range s.Values {
+			fmt.Fprintf(&b, `<circle class="series series-%d" cx="%.1f" cy="%.1f" r="3"><title>%s: %d (%s)</title></circle>`,
+				i, x(j), y(v), html.EscapeString(s.Name), v, times[j].UTC().Format("Jan 2, 2006 15:04 MST"))
+		}
+	}
+	b.WriteString(`</svg>`)
+
+	// Legend, only needed when several series share the chart
+	if len(series) > 1 {
+		b.WriteString(`<div class="trend-legend">`)
+		for i, s := range series {
+			fmt.Fprintf(&b, `<span class="trend-legend-item series-%d">%s</span>`, i, html.EscapeString(s.Name))
+		}
+		b.WriteString(`</div>`)
+	}
+
+	return template.HTML(b.String())
+}
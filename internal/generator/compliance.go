@@ -0,0 +1,83 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/catalog"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/policy"
+)
+
+// buildCompliance evaluates the policy for every repository, returning the
+// per-repository results keyed by repository name and the dashboard overview.
+func buildCompliance(p *policy.Policy, repos []models.RepositoryData, orgName string, config *catalog.Config) (map[string]*RepoCompliance, *ComplianceOverview) {
+	results := make(map[string]*RepoCompliance, len(repos))
+	overview := &ComplianceOverview{}
+
+	ruleIndex := make(map[string]int, len(p.Rules))
+	for i, rule := range p.Rules {
+		ruleIndex[rule.Name] = i
+		overview.Rules = append(overview.Rules, RuleOverview{
+			Name:        rule.Name,
+			Description: rule.Description,
+		})
+	}
+
+	for _, repo := range repos {
+		result := p.Evaluate(repo, policy.Identify(repo, orgName, config))
+		compliance := &RepoCompliance{
+			Repository: repo.Repository,
+			Compliant:  result.Compliant(),
+		}
+		for _, r := range result.Rules {
+			compliance.Rules = append(compliance.Rules, RuleCompliance{
+				Name:        r.Rule.Name,
+				Description: r.Rule.Description,
+				Passed:      r.Passed(),
+				Problems:    ruleProblems(r),
+			})
+
+			stats := &overview.Rules[ruleIndex[r.Rule.Name]]
+			stats.InScope++
+			if r.Passed() {
+				stats.Passing++
+			} else {
+				stats.Failing++
+			}
+		}
+		results[repo.Repository] = compliance
+
+		if !result.Applicable() {
+			continue
+		}
+		overview.InScope++
+		if compliance.Compliant {
+			overview.Compliant++
+		} else {
+			overview.NonCompliant++
+			overview.NonCompliantRepos = append(overview.NonCompliantRepos, *compliance)
+		}
+	}
+
+	sort.Slice(overview.NonCompliantRepos, func(i, j int) bool {
+		return overview.NonCompliantRepos[i].Repository < overview.NonCompliantRepos[j].Repository
+	})
+	return results, overview
+}
+
+// ruleProblems describes why a repository fails a rule.
+func ruleProblems(r policy.RuleResult) []string {
+	var problems []string
+	for _, s := range r.Missing {
+		problems = append(problems, fmt.Sprintf("Missing badge with %s", s))
+	}
+	for _, v := range r.Forbidden {
+		if v.Badge.Name == catalog.Unknown {
+			problems = append(problems, fmt.Sprintf("Forbidden badge %s", v.Badge.Pattern))
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("Forbidden badge %s (matches %s)", v.Badge.Name, v.Selector))
+	}
+	return problems
+}
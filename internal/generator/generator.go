@@ -1,27 +1,62 @@
 package generator
 
 import (
-	"crypto/sha256"
 	"embed"
-	"encoding/hex"
-	"encoding/json"
 	"fmt"
 	"html/template"
 	"io/fs"
-	"net/url"
+	"maps"
 	"os"
 	"path/filepath"
-	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/catalog"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/dataset"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/liveness"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/mirror"
 	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/policy"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/sarif"
 )
 
+// Options configures a generation run.
+type Options struct {
+	// Source is the crawl directory, bundle or SQLite store to read.
+	Source dataset.Source
+	// Catalog names and categorizes badges.
+	Catalog *catalog.Config
+	// SnapshotDir holds archived crawls used, together with the runs of a
+	// SQLite source, to chart badge adoption over time.
+	SnapshotDir string
+	// Policy, when set, is evaluated against every repository.
+	Policy *policy.Policy
+	// SARIFPath, when set with a Policy, receives the policy violations as a
+	// SARIF log.
+	SARIFPath string
+	// Mirror, when set, downloads badge images into the output directory
+	// and serves them from there instead of from the badge services.
+	Mirror *mirror.Options
+	// CSP declares a strict Content-Security-Policy in every page.
+	CSP bool
+	// BasePath is the URL or path the site is served under, such as
+	// https://org.github.io/project/ for a GitHub Pages project site. Only
+	// its path is used; empty serves the site from a domain root.
+	BasePath   string
+	OutputDir  string
+	TemplateFS embed.FS
+}
+
 // Run executes the generation phase.
-func Run(inputDir, outputDir string, templateFS embed.FS) error {
-	fmt.Printf("Starting generation from: %s, output to: %s\n", inputDir, outputDir)
+func Run(opts Options) error {
+	outputDir := opts.OutputDir
+	basePath, err := normalizeBasePath(opts.BasePath)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Starting generation from: %s, output to: %s\n", opts.Source, outputDir)
 
 	// Ensure output directories exist
 	for _, dir := range []string{
@@ -36,57 +71,67 @@ func Run(inputDir, outputDir string, templateFS embed.FS) error {
 		}
 	}
 
-	// Load badge configuration
-	badgeConfig := loadBadgeConfig("badges.json")
-
-	// Load timestamp
-	lastUpdated := loadTimestamp(inputDir)
+	badgeConfig := opts.Catalog
 
 	// Load Data
-	files, err := filepath.Glob(filepath.Join(inputDir, "*.json"))
+	crawl, err := dataset.Load(opts.Source)
 	if err != nil {
-		return fmt.Errorf("failed to list input files: %w", err)
+		return err
 	}
+	repos := crawl.Repositories
+	org := crawl.Profile
+	lastUpdated := formatTimestamp(crawl.CrawledAt)
 
-	var repos []models.RepositoryData
-	var orgName string
-	for _, f := range files {
-		if strings.HasSuffix(f, "timestamp.json") {
-			continue
-		}
+	orgName := dataset.OrganizationName(crawl)
 
-		file, err := os.Open(f)
-		if err != nil {
-			return fmt.Errorf("failed to open %s: %w", f, err)
-		}
+	// Load archived crawls for trend charts
+	archived, err := dataset.LoadHistory(opts.Source, opts.SnapshotDir)
+	if err != nil {
+		return fmt.Errorf("failed to load crawl history: %w", err)
+	}
+	history := buildHistory(archived, crawl)
 
-		var repo models.RepositoryData
-		if err := json.NewDecoder(file).Decode(&repo); err != nil {
-			file.Close()
-			return fmt.Errorf("failed to decode %s: %w", f, err)
-		}
-		file.Close()
-		repos = append(repos, repo)
-
-		// Extract org name from repository URL (e.g., https://github.com/OrgName/RepoName)
-		if orgName == "" && repo.RepositoryURL != "" {
-			if u, err := url.Parse(repo.RepositoryURL); err == nil {
-				parts := strings.Split(strings.Trim(u.Path, "/"), "/")
-				if len(parts) >= 1 {
-					orgName = parts[0]
-				}
-			}
+	// Build ViewModels
+	dashboardVM, badgeMap, err := buildDashboard(repos, badgeConfig, orgName, lastUpdated)
+	if err != nil {
+		return err
+	}
+	dashboardVM.Organization = buildOrganizationSummary(org, badgeConfig, badgeMap)
+	dashboardVM.Trend = history.dashboardChart()
+
+	// Explain catalog mistakes that would otherwise only show up as Unknown badges
+	for _, problem := range catalog.Validate(badgeConfig, slices.Collect(maps.Keys(badgeMap))) {
+		fmt.Printf("Badge catalog %s\n", problem)
+	}
+
+	var compliance map[string]*RepoCompliance
+	if opts.Policy != nil {
+		compliance, dashboardVM.Compliance = buildCompliance(opts.Policy, repos, orgName, badgeConfig)
+	}
+	if opts.SARIFPath != "" {
+		if err := sarif.Write(opts.SARIFPath, sarif.Build(crawl, opts.Policy, badgeConfig)); err != nil {
+			return err
 		}
+		fmt.Printf("Wrote badge findings to %s\n", opts.SARIFPath)
 	}
 
-	// Build ViewModels
-	dashboardVM, badgeMap := buildDashboard(repos, badgeConfig, orgName, lastUpdated)
+	// Serve local copies of badge images when mirroring
+	image := func(imageURL string) string { return siteImage(basePath, imageURL) }
+	hotlinked := true
+	if opts.Mirror != nil {
+		if image, hotlinked, err = mirrorImages(outputDir, basePath, repos, org, badgeMap, *opts.Mirror); err != nil {
+			return err
+		}
+	}
+	site := buildSite(basePath, opts.CSP, hotlinked)
+	dashboardVM.Site = site
 
 	// Parse Templates
 	funcMap := template.FuncMap{
 		"urlize": normalizeRepoName,
+		"image":  image,
 	}
-	tmpl, err := loadTemplates(funcMap, templateFS)
+	tmpl, err := loadTemplates(funcMap, opts.TemplateFS)
 	if err != nil {
 		return fmt.Errorf("failed to parse templates: %w", err)
 	}
@@ -107,22 +152,29 @@ func Run(inputDir, outputDir string, templateFS embed.FS) error {
 		// Build enhanced badge list with names/categories
 		var repoBadges []RepoBadge
 		for _, b := range repo.Badges {
-			pattern := canonicalizeURL(b.ImageURL, orgName, repo.Repository)
-			name, category, _, id := lookupBadge(pattern, badgeConfig)
+			pattern := catalog.Canonicalize(b.ImageURL, orgName, repo.Repository)
+			name, category, _, _ := badgeConfig.Lookup(pattern)
 			repoBadges = append(repoBadges, RepoBadge{
 				ImageURL:  b.ImageURL,
 				TargetURL: b.TargetURL,
 				AltText:   b.AltText,
 				Name:      name,
 				Category:  category,
-				ID:        id,
+				ID:        badgeMap[pattern].ID,
+				Source:    b.Source,
+				Problem:   badgeProblem(b),
+				Text:      badgeText(b),
+				State:     liveness.State(b.ImageStatus),
 			})
 		}
 
 		vm := RepoPageViewModel{
+			Site:        site,
 			OrgName:     orgName,
 			Repository:  repo,
 			Badges:      repoBadges,
+			Files:       groupBadgesByFile(repo, repoBadges),
+			Compliance:  compliance[repo.Repository],
 			LastUpdated: lastUpdated,
 		}
 		baseName := normalizeRepoName(repo.Repository)
@@ -143,37 +195,50 @@ func Run(inputDir, outputDir string, templateFS embed.FS) error {
 
 		// Build repo list with badge info for each repo
 		var repoBadges []BadgeRepoBadge
+		broken := 0
 		for _, repoName := range info.Repos {
 			// Find the original badge URL for this repo
 			imageURL := info.SampleImage
 			targetURL := ""
+			problem, text, state := "", "", ""
 			for _, repo := range repos {
 				if repo.Repository == repoName {
 					for _, b := range repo.Badges {
-						p := canonicalizeURL(b.ImageURL, orgName, repo.Repository)
+						p := catalog.Canonicalize(b.ImageURL, orgName, repo.Repository)
 						if p == pattern {
 							imageURL = b.ImageURL
 							targetURL = b.TargetURL
+							problem = badgeProblem(b)
+							text, state = badgeText(b), liveness.State(b.ImageStatus)
 							break
 						}
 					}
 					break
 				}
 			}
+			if problem != "" {
+				broken++
+			}
 			repoBadges = append(repoBadges, BadgeRepoBadge{
 				RepoName:  repoName,
 				ImageURL:  imageURL,
 				TargetURL: targetURL,
+				Problem:   problem,
+				Text:      text,
+				State:     state,
 			})
 		}
 
 		vm := BadgePageViewModel{
+			Site:         site,
 			OrgName:      orgName,
 			ImageURL:     info.SampleImage,
 			Pattern:      pattern,
 			Name:         info.Name,
 			Category:     info.Category,
 			Repositories: repoBadges,
+			Broken:       broken,
+			Trend:        history.badgeChart(pattern),
 			LastUpdated:  lastUpdated,
 		}
 
@@ -187,25 +252,144 @@ func Run(inputDir, outputDir string, templateFS embed.FS) error {
 		}
 	}
 
+	// Keep the pages of badges named since they were unknown reachable
+	for oldID, id := range redirects(badgeMap) {
+		vm := RedirectViewModel{Site: site, OrgName: orgName, ID: id}
+		if err := renderTemplate(tmpl, filepath.Join(outputDir, "badges", oldID+".html"), "redirect.html", vm); err != nil {
+			return err
+		}
+	}
+
 	// Copy Assets from embedded filesystem
-	if err := copyEmbeddedFile(templateFS, "templates/style.css", filepath.Join(outputDir, "style.css")); err != nil {
-		return fmt.Errorf("failed to copy style.css: %w", err)
+	if err := copyAssets(opts.TemplateFS, outputDir); err != nil {
+		return err
 	}
 
 	fmt.Println("Generation complete.")
 	return nil
 }
 
+// badgeProblem describes a broken badge image or link recorded by the last
+// liveness check, or is empty when none was found.
+func badgeProblem(b models.Badge) string {
+	if problem := liveness.ImageProblem(b.ImageStatus); problem != "" {
+		return "Image: " + problem
+	}
+	if problem := liveness.TargetProblem(b.TargetStatus); problem != "" {
+		return "Link: " + problem
+	}
+	return ""
+}
+
+// badgeText is the text read from a badge image by the last liveness check,
+// such as "build: passing".
+func badgeText(b models.Badge) string {
+	if b.ImageStatus == nil || b.ImageStatus.Message == "" {
+		return ""
+	}
+	if b.ImageStatus.Label == "" {
+		return b.ImageStatus.Message
+	}
+	return b.ImageStatus.Label + ": " + b.ImageStatus.Message
+}
+
+// groupBadgesByFile groups badges by source file, keeping the README first
+// and ordering other files by path. Badges crawled before sources were
+// recorded are attributed to the README.
+func groupBadgesByFile(repo models.RepositoryData, badges []RepoBadge) []RepoBadgeFile {
+	var files []RepoBadgeFile
+	index := make(map[string]int)
+	for _, b := range badges {
+		i, ok := index[b.Source]
+		if !ok {
+			i = len(files)
+			index[b.Source] = i
+			files = append(files, RepoBadgeFile{Path: b.Source, URL: sourceURL(repo, b.Source)})
+		}
+		files[i].Badges = append(files[i].Badges, b)
+	}
+
+	// The README comes first, then the other files by path. Older crawls
+	// record README badges without a source.
+	readme := repo.ReadmePath
+	if readme == "" {
+		readme = "README.md"
+	}
+	isReadme := func(path string) bool { return path == "" || path == readme }
+	sort.SliceStable(files, func(i, j int) bool {
+		if isReadme(files[i].Path) != isReadme(files[j].Path) {
+			return isReadme(files[i].Path)
+		}
+		return files[i].Path < files[j].Path
+	})
+	return files
+}
+
+// sourceURL links to a crawled file on GitHub at the indexed ref.
+func sourceURL(repo models.RepositoryData, source string) string {
+	if repo.RepositoryURL == "" || source == "" {
+		return ""
+	}
+	ref := repo.Ref
+	if ref == "" {
+		ref = repo.DefaultBranch
+	}
+	return fmt.Sprintf("%s/blob/%s/%s", strings.TrimSuffix(repo.RepositoryURL, "/"), ref, source)
+}
+
+// buildOrganizationSummary classifies the organization profile badges. Badge
+// page IDs are only set when a badge page exists for the pattern, since pages
+// are generated from repository usage.
+func buildOrganizationSummary(org *models.OrganizationData, config *catalog.Config, badgeMap map[string]*badgeInfo) *OrganizationSummary {
+	if org == nil {
+		return nil
+	}
+
+	summary := &OrganizationSummary{
+		Name:        org.Organization,
+		ProfileURL:  org.ProfileURL,
+		ReadmeURL:   org.ReadmeURL,
+		ReadmeFound: org.ReadmeFound,
+	}
+	for _, b := range org.Badges {
+		pattern := catalog.Canonicalize(b.ImageURL, org.Organization, ".github")
+		name, category, _, _ := config.Lookup(pattern)
+		id := ""
+		if info, ok := badgeMap[pattern]; ok {
+			id = info.ID
+		}
+		summary.Badges = append(summary.Badges, RepoBadge{
+			ImageURL:  b.ImageURL,
+			TargetURL: b.TargetURL,
+			AltText:   b.AltText,
+			Name:      name,
+			Category:  category,
+			ID:        id,
+			Source:    b.Source,
+			Problem:   badgeProblem(b),
+		})
+	}
+	return summary
+}
+
 type badgeInfo struct {
 	SampleImage string
 	Placeholder string
 	Name        string
 	Category    string
-	ID          string
-	Repos       []string
+	// ID is the catalog ID until assignPageIDs sets the badge page ID.
+	ID    string
+	Repos []string
+	// CatalogID is the ID of the matching catalog entry, and UserEntry
+	// whether that entry comes from the user's catalog, not the defaults.
+	CatalogID string
+	UserEntry bool
+	// LegacyIDs are the page IDs the badge had while unknown before
+	// patterns were normalized; see redirects.
+	LegacyIDs []string
 }
 
-func buildDashboard(repos []models.RepositoryData, config *BadgeConfig, orgName, lastUpdated string) (DashboardViewModel, map[string]*badgeInfo) {
+func buildDashboard(repos []models.RepositoryData, config *catalog.Config, orgName, lastUpdated string) (DashboardViewModel, map[string]*badgeInfo, error) {
 	vm := DashboardViewModel{
 		OrgName:     orgName,
 		TotalRepos:  len(repos),
@@ -215,6 +399,7 @@ func buildDashboard(repos []models.RepositoryData, config *BadgeConfig, orgName,
 	badgeMap := make(map[string]*badgeInfo) // CanonicalPattern -> info
 
 	var repoSummaries []RepoSummary
+	var repoBadges [][]models.Badge
 	for _, r := range repos {
 		vm.TotalBadges += len(r.Badges)
 
@@ -231,12 +416,11 @@ func buildDashboard(repos []models.RepositoryData, config *BadgeConfig, orgName,
 		}
 
 		for _, b := range r.Badges {
-			pattern := canonicalizeURL(b.ImageURL, orgName, r.Repository)
-			_, _, _, id := lookupBadge(pattern, config)
-			summary.BadgeIDs = append(summary.BadgeIDs, id)
+			pattern := catalog.Canonicalize(b.ImageURL, orgName, r.Repository)
 
 			if _, exists := badgeMap[pattern]; !exists {
-				name, category, placeholder, id := lookupBadge(pattern, config)
+				entry, found := config.Matcher().Find(pattern)
+				name, category, placeholder, id := catalog.Describe(pattern, entry, found)
 				displayImage := b.ImageURL
 				if placeholder != "" {
 					displayImage = placeholder
@@ -248,12 +432,44 @@ func buildDashboard(repos []models.RepositoryData, config *BadgeConfig, orgName,
 					Category:    category,
 					ID:          id,
 					Repos:       []string{},
+					CatalogID:   entry.ID,
+					UserEntry:   found && !entry.Default,
+				}
+			}
+			info := badgeMap[pattern]
+			info.Repos = append(info.Repos, r.Repository)
+			// Badges the user's catalog names had their catalog ID then too
+			if !info.UserEntry {
+				if old := legacyID(b.ImageURL, orgName, r.Repository); !slices.Contains(info.LegacyIDs, old) {
+					info.LegacyIDs = append(info.LegacyIDs, old)
 				}
 			}
-			badgeMap[pattern].Repos = append(badgeMap[pattern].Repos, r.Repository)
 		}
 
 		repoSummaries = append(repoSummaries, summary)
+		repoBadges = append(repoBadges, r.Badges)
+	}
+
+	if err := assignPageIDs(badgeMap); err != nil {
+		return vm, nil, err
+	}
+	for i, badges := range repoBadges {
+		summary := &repoSummaries[i]
+		for _, b := range badges {
+			id := badgeMap[catalog.Canonicalize(b.ImageURL, orgName, summary.Name)].ID
+			summary.BadgeIDs = append(summary.BadgeIDs, id)
+
+			// A failing badge outweighs passing ones
+			state := liveness.State(b.ImageStatus)
+			if state == "" {
+				continue
+			}
+			summary.BadgeStates = append(summary.BadgeStates, id+":"+state)
+			vm.HasStates = true
+			if summary.State != liveness.StateFailing {
+				summary.State = state
+			}
+		}
 	}
 
 	// Sort Repos
@@ -287,14 +503,14 @@ func buildDashboard(repos []models.RepositoryData, config *BadgeConfig, orgName,
 	var hasUnknown bool
 	var filtered []string
 	for _, cat := range categories {
-		if cat == "Unknown" {
+		if cat == catalog.Unknown {
 			hasUnknown = true
 		} else {
 			filtered = append(filtered, cat)
 		}
 	}
 	if hasUnknown {
-		categories = append(filtered, "Unknown")
+		categories = append(filtered, catalog.Unknown)
 	} else {
 		categories = filtered
 	}
@@ -312,76 +528,7 @@ func buildDashboard(repos []models.RepositoryData, config *BadgeConfig, orgName,
 
 	vm.UniqueBadgeCount = len(badgeMap)
 
-	return vm, badgeMap
-}
-
-func loadBadgeConfig(path string) *BadgeConfig {
-	file, err := os.Open(path)
-	if err != nil {
-		return &BadgeConfig{}
-	}
-	defer file.Close()
-
-	var config BadgeConfig
-	if err := json.NewDecoder(file).Decode(&config); err != nil {
-		return &BadgeConfig{}
-	}
-	return &config
-}
-
-func lookupBadge(pattern string, config *BadgeConfig) (name, category, placeholder, id string) {
-	for _, entry := range config.Badges {
-		// Convert the config pattern to a regex
-		regexPattern := regexp.QuoteMeta(entry.Pattern)
-		regexPattern = strings.ReplaceAll(regexPattern, `\{ORG\}`, `[^/]+`)
-		regexPattern = strings.ReplaceAll(regexPattern, `\{REPO\}`, `[^/]+`)
-		regexPattern = strings.ReplaceAll(regexPattern, `\.*`, `.*`)
-
-		re, err := regexp.Compile("^" + regexPattern + "$")
-		if err != nil {
-			continue
-		}
-
-		if re.MatchString(pattern) {
-			return entry.Name, entry.Category, entry.Placeholder, entry.ID
-		}
-	}
-	return "Unknown", "Unknown", "", normalizeRepoName(pattern)
-}
-
-func canonicalizeURL(rawURL, org, repo string) string {
-	result := rawURL
-	lowerURL := strings.ToLower(rawURL)
-	lowerOrg := strings.ToLower(org)
-	lowerRepo := strings.ToLower(repo)
-
-	// Replace Org
-	if idx := strings.Index(lowerURL, lowerOrg); idx != -1 {
-		result = result[:idx] + "{ORG}" + result[idx+len(org):]
-		lowerURL = strings.ToLower(result)
-	}
-
-	// Replace Repo - always add /.* after {REPO} for consistency
-	if idx := strings.Index(lowerURL, lowerRepo); idx != -1 {
-		result = result[:idx] + "{REPO}/.*"
-	}
-
-	// Remove token query param if present
-	u, err := url.Parse(result)
-	if err == nil {
-		q := u.Query()
-		if q.Get("token") != "" {
-			q.Del("token")
-			if len(q) == 0 {
-				u.RawQuery = ""
-			} else {
-				u.RawQuery = q.Encode()
-			}
-			result = u.String()
-		}
-	}
-
-	return result
+	return vm, badgeMap, nil
 }
 
 func renderTemplate(tmpl *template.Template, path, name string, data any) error {
@@ -441,36 +588,11 @@ func normalizeRepoName(name string) string {
 	return strings.ReplaceAll(strings.ToLower(name), "/", "-")
 }
 
-func hashString(s string) string {
-	h := sha256.New()
-	h.Write([]byte(s))
-	return hex.EncodeToString(h.Sum(nil))[:12]
-}
-
-func loadTimestamp(inputDir string) string {
-	tsPath := filepath.Join(inputDir, "timestamp.json")
-	file, err := os.Open(tsPath)
-	if err != nil {
+// formatTimestamp formats the crawl time for display.
+func formatTimestamp(t time.Time) string {
+	if t.IsZero() {
 		return "Unknown"
 	}
-	defer file.Close()
-
-	var data map[string]string
-	if err := json.NewDecoder(file).Decode(&data); err != nil {
-		return "Unknown"
-	}
-
-	lastCrawled, ok := data["last_crawled"]
-	if !ok {
-		return "Unknown"
-	}
-
-	// Parse RFC3339Nano timestamp
-	t, err := time.Parse(time.RFC3339Nano, lastCrawled)
-	if err != nil {
-		return "Unknown"
-	}
-
 	// Format as "January 2, 2006 15:04 MST"
 	return t.UTC().Format("January 2, 2006 15:04 MST")
 }
@@ -0,0 +1,290 @@
+package generator
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/catalog"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/dataset"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/policy"
+)
+
+// linkAttr finds the URLs pages link to, load or swap in, including the
+// target of redirect pages.
+var linkAttr = regexp.MustCompile(`(?:href|src|hx-get|hx-push-url)="([^"]*)"|url=([^"]*)"`)
+
+// TestVendoredHTMX checks that the vendored htmx is the pinned release.
+func TestVendoredHTMX(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile(filepath.Join("..", "..", "templates", htmxFile))
+	if err != nil {
+		t.Fatalf("htmx is not vendored: %v", err)
+	}
+	sum := sha512.Sum384(data)
+	if got := "sha384-" + base64.StdEncoding.EncodeToString(sum[:]); got != htmxIntegrity {
+		t.Errorf("templates/%s has integrity %s, want %s", htmxFile, got, htmxIntegrity)
+	}
+}
+
+func TestNormalizeBasePath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{raw: "", want: ""},
+		{raw: "/", want: ""},
+		{raw: "project", want: "/project"},
+		{raw: "/project/", want: "/project"},
+		{raw: "/a//b/../c/", want: "/a/c"},
+		{raw: "https://org.github.io/project/", want: "/project"},
+		{raw: "https://example.com", want: ""},
+		{raw: "/my project", want: "/my%20project"},
+		{raw: "/project?x=1", wantErr: true},
+		{raw: "/project#top", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeBasePath(tt.raw)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("normalizeBasePath(%q) = %q, %v, want %q, error %v", tt.raw, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// TestRunBasePath renders a site under a prefix and checks that every
+// internal link stays under it and resolves to a generated file.
+func TestRunBasePath(t *testing.T) {
+	t.Setenv("TEMPLATE_PATH", filepath.Join("..", "..", "templates"))
+
+	data := t.TempDir()
+	bundle := &models.Bundle{
+		Organization: "Org",
+		CrawledAt:    time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC),
+		Profile: &models.OrganizationData{
+			Organization: "Org",
+			ProfileURL:   "https://github.com/Org",
+			ReadmeFound:  true,
+			Badges: []models.Badge{
+				{AltText: "License", ImageURL: "https://img.shields.io/badge/license-MIT-blue.svg", TargetURL: "https://opensource.org/licenses/MIT"},
+			},
+		},
+		Repositories: []models.RepositoryData{
+			{
+				Repository:    "alpha",
+				RepositoryURL: "https://github.com/Org/alpha",
+				DefaultBranch: "main",
+				ReadmeFound:   true,
+				Badges: []models.Badge{
+					{AltText: "License", ImageURL: "https://img.shields.io/badge/license-MIT-blue.svg", TargetURL: "https://opensource.org/licenses/MIT", Source: "README.md"},
+					{AltText: "Build", ImageURL: "https://github.com/Org/alpha/actions/workflows/build.yml/badge.svg", TargetURL: "https://github.com/Org/alpha/actions", Source: "README.md"},
+				},
+			},
+			{
+				Repository:    "Beta.Project",
+				RepositoryURL: "https://github.com/Org/Beta.Project",
+				DefaultBranch: "main",
+				ReadmeFound:   true,
+				Badges: []models.Badge{
+					{AltText: "Custom", ImageURL: "https://badges.example.com/Org/Beta.Project.svg", TargetURL: "https://example.com", Source: "README.md"},
+				},
+			},
+		},
+	}
+	if err := dataset.WriteDirectory(data, bundle); err != nil {
+		t.Fatalf("WriteDirectory() error = %v", err)
+	}
+
+	// A site-relative placeholder is served from the site, under the base path
+	out := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(out, "img"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(out, "img", "custom.svg"), []byte("<svg/>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := &catalog.Config{Badges: []catalog.Entry{{
+		ID:          "custom",
+		Pattern:     catalog.Canonicalize("https://badges.example.com/Org/Beta.Project.svg", "Org", "Beta.Project"),
+		Name:        "Custom",
+		Category:    "Other",
+		Placeholder: "/img/custom.svg",
+	}}}
+	err := Run(Options{
+		Source:  dataset.Source{Dir: data},
+		Catalog: config.WithDefaults(catalog.Defaults()),
+		// A failing rule puts repository links in the compliance overview
+		Policy:    &policy.Policy{Rules: []policy.Rule{{Name: "build", Require: []policy.Selector{{Category: "Build"}}}}},
+		OutputDir: out,
+		BasePath:  "https://org.github.io/project/",
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	links := 0
+	err = filepath.WalkDir(out, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".html" {
+			return err
+		}
+		page, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(out, path)
+		for _, m := range linkAttr.FindAllStringSubmatch(string(page), -1) {
+			link := m[1] + m[2]
+			if strings.Contains(link, "://") {
+				continue
+			}
+			links++
+			target, ok := strings.CutPrefix(link, "/project/")
+			if !ok {
+				t.Errorf("%s links to %q outside the base path", rel, link)
+				continue
+			}
+			if target == "" {
+				target = "index.html"
+			}
+			if _, err := os.Stat(filepath.Join(out, filepath.FromSlash(target))); err != nil {
+				t.Errorf("%s links to %q, which was not generated", rel, link)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walking output: %v", err)
+	}
+	if links == 0 {
+		t.Fatal("found no internal links")
+	}
+	page, err := os.ReadFile(filepath.Join(out, "badges", "custom.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(page), `src="/project/img/custom.svg"`) {
+		t.Errorf("badge page does not show the placeholder under the base path")
+	}
+}
+
+func TestGroupBadgesByFile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		readme  string
+		sources []string
+		want    []string
+	}{
+		{name: "readme first", readme: "README.md", sources: []string{"docs/b.md", "README.md", "docs/a.md"}, want: []string{"README.md", "docs/a.md", "docs/b.md"}},
+		{name: "no readme badges", readme: "README.md", sources: []string{"docs/b.md", "docs/a.md"}, want: []string{"docs/a.md", "docs/b.md"}},
+		{name: "readme path", readme: "docs/README.rst", sources: []string{"CONTRIBUTING.md", "docs/README.rst"}, want: []string{"docs/README.rst", "CONTRIBUTING.md"}},
+		{name: "default readme", sources: []string{"a.md", "README.md"}, want: []string{"README.md", "a.md"}},
+		{name: "older crawl", sources: []string{"", ""}, want: []string{""}},
+	}
+	for _, tt := range tests {
+		var badges []RepoBadge
+		for _, source := range tt.sources {
+			badges = append(badges, RepoBadge{Source: source})
+		}
+		var got []string
+		for _, file := range groupBadgesByFile(models.RepositoryData{ReadmePath: tt.readme}, badges) {
+			got = append(got, file.Path)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: groupBadgesByFile() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestRepoSnippetWithoutReadme checks that badges found outside a missing
+// README are shown instead of the no-README note.
+func TestRepoSnippetWithoutReadme(t *testing.T) {
+	t.Parallel()
+
+	tmpl, err := template.New("").Funcs(template.FuncMap{
+		"urlize": normalizeRepoName,
+		"image":  func(imageURL string) string { return imageURL },
+	}).ParseGlob(filepath.Join("..", "..", "templates", "*.html"))
+	if err != nil {
+		t.Fatalf("parsing templates: %v", err)
+	}
+
+	repo := models.RepositoryData{Repository: "tool", ReadmeFound: false}
+	badges := []RepoBadge{{ImageURL: "https://ci.example.com/tool.svg", AltText: "Build", Name: "Build", ID: "build", Source: "docs/index.md"}}
+	tests := []struct {
+		name   string
+		badges []RepoBadge
+		want   string
+		absent string
+	}{
+		{name: "badges in other files", badges: badges, want: "https://ci.example.com/tool.svg", absent: "No README found"},
+		{name: "nothing found", want: "No README found", absent: "<img"},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		vm := RepoPageViewModel{Site: &Site{}, Repository: repo, Badges: tt.badges, Files: groupBadgesByFile(repo, tt.badges)}
+		if err := tmpl.ExecuteTemplate(&b, "repo_snippet.html", vm); err != nil {
+			t.Fatalf("%s: rendering: %v", tt.name, err)
+		}
+		if !strings.Contains(b.String(), tt.want) || strings.Contains(b.String(), tt.absent) {
+			t.Errorf("%s: page contains %q: %v, %q: %v, want only the first", tt.name, tt.want, strings.Contains(b.String(), tt.want), tt.absent, strings.Contains(b.String(), tt.absent))
+		}
+	}
+}
+
+// TestRedirects checks that old badge page IDs redirect only where such a
+// page could have been published.
+func TestRedirects(t *testing.T) {
+	t.Parallel()
+
+	named := catalog.Canonicalize("https://ci.example.com/Org/alpha/status.svg", "Org", "alpha")
+	config := (&catalog.Config{Badges: []catalog.Entry{
+		{ID: "ci", Pattern: named, Name: "CI", Category: "Build"},
+	}}).WithDefaults(catalog.Defaults())
+	repos := []models.RepositoryData{{
+		Repository: "alpha",
+		Badges: []models.Badge{
+			{ImageURL: "https://img.shields.io/badge/license-MIT-blue.svg"},
+			{ImageURL: "https://badges.example.com/Org/alpha/coverage.svg"},
+			{ImageURL: "https://ci.example.com/Org/alpha/status.svg"},
+			{ImageURL: "https://stats.example.com/card.svg?user=Org"},
+		},
+	}}
+	_, badgeMap, err := buildDashboard(repos, config, "Org", "")
+	if err != nil {
+		t.Fatalf("buildDashboard() error = %v", err)
+	}
+	pageID := func(rawURL string) string {
+		return badgeMap[catalog.Canonicalize(rawURL, "Org", "alpha")].ID
+	}
+
+	got := redirects(badgeMap)
+	want := map[string]string{
+		// Unknown before the defaults named it
+		"https:--img.shields.io-badge-license-mit-blue.svg": pageID("https://img.shields.io/badge/license-MIT-blue.svg"),
+		// Still unknown, under a new ID
+		"https:--badges.example.com-{org}-{repo}-.*": pageID("https://badges.example.com/Org/alpha/coverage.svg"),
+		// Unknown until the user's catalog named it
+		catalog.PatternID(named): "ci",
+	}
+	for old, id := range want {
+		if got[old] != id {
+			t.Errorf("redirects()[%q] = %q, want %q", old, got[old], id)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("redirects() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,90 @@
+package generator
+
+import (
+	"html/template"
+	"sort"
+	"time"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/catalog"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/dataset"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+)
+
+// badgeHistory holds adoption counts for each archived crawl, oldest first.
+type badgeHistory struct {
+	times           []time.Time
+	repos           []int
+	reposWithBadges []int
+	// patterns maps canonical badge patterns to the number of repositories
+	// using them, one map per crawl.
+	patterns []map[string]int
+}
+
+// buildHistory counts badge adoption across the archived crawls and the
+// current one. Crawls recorded in more than one place, such as the current
+// crawl also archived as a snapshot, are counted once.
+func buildHistory(history []*models.Bundle, current *models.Bundle) *badgeHistory {
+	var bundles []*models.Bundle
+	for _, bundle := range append(history, current) {
+		if bundle != nil && !bundle.CrawledAt.IsZero() {
+			bundles = append(bundles, bundle)
+		}
+	}
+	sort.SliceStable(bundles, func(i, j int) bool {
+		return bundles[i].CrawledAt.Before(bundles[j].CrawledAt)
+	})
+
+	h := &badgeHistory{}
+	seen := make(map[time.Time]struct{})
+	for _, bundle := range bundles {
+		at := bundle.CrawledAt.UTC()
+		if _, ok := seen[at]; ok {
+			continue
+		}
+		seen[at] = struct{}{}
+
+		orgName := dataset.OrganizationName(bundle)
+
+		withBadges := 0
+		counts := make(map[string]int)
+		for _, repo := range bundle.Repositories {
+			if len(repo.Badges) > 0 {
+				withBadges++
+			}
+			// Count each repository once per pattern
+			repoPatterns := make(map[string]struct{})
+			for _, b := range repo.Badges {
+				repoPatterns[catalog.Canonicalize(b.ImageURL, orgName, repo.Repository)] = struct{}{}
+			}
+			for pattern := range repoPatterns {
+				counts[pattern]++
+			}
+		}
+
+		h.times = append(h.times, at)
+		h.repos = append(h.repos, len(bundle.Repositories))
+		h.reposWithBadges = append(h.reposWithBadges, withBadges)
+		h.patterns = append(h.patterns, counts)
+	}
+
+	return h
+}
+
+// dashboardChart charts repository totals and badge coverage over time.
+func (h *badgeHistory) dashboardChart() template.HTML {
+	return renderTrendChart(h.times, []chartSeries{
+		{Name: "Repositories", Values: h.repos},
+		{Name: "With Badges", Values: h.reposWithBadges},
+	})
+}
+
+// badgeChart charts the number of repositories using a badge pattern.
+func (h *badgeHistory) badgeChart(pattern string) template.HTML {
+	values := make([]int, len(h.patterns))
+	for i, counts := range h.patterns {
+		values[i] = counts[pattern]
+	}
+	return renderTrendChart(h.times, []chartSeries{
+		{Name: "Repositories", Values: values},
+	})
+}
@@ -0,0 +1,127 @@
+package generator
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/catalog"
+)
+
+// assignPageIDs replaces the catalog IDs of the badge patterns with the IDs
+// of their badge pages, which are safe in URLs and file names and unique
+// even when several patterns share a catalog entry. The pattern used by the
+// most repositories keeps the bare ID, so links to a catalog badge keep
+// working; the others get a variant ID derived from their pattern.
+func assignPageIDs(badgeMap map[string]*badgeInfo) error {
+	shared := make(map[string][]string)
+	for pattern, info := range badgeMap {
+		id := catalog.SafeID(info.ID)
+		shared[id] = append(shared[id], pattern)
+	}
+
+	owners := make(map[string]string)
+	for _, id := range slices.Sorted(maps.Keys(shared)) {
+		patterns := shared[id]
+		slices.SortFunc(patterns, func(a, b string) int {
+			if n := len(badgeMap[b].Repos) - len(badgeMap[a].Repos); n != 0 {
+				return n
+			}
+			return strings.Compare(a, b)
+		})
+		for i, pattern := range patterns {
+			pageID := id
+			if i > 0 {
+				pageID = catalog.VariantID(id, pattern)
+			}
+			// Pages differing only in case would overwrite each other on
+			// case-insensitive filesystems
+			key := strings.ToLower(pageID)
+			if other, ok := owners[key]; ok {
+				return fmt.Errorf("badge page ID %q is used by both %s and %s", pageID, other, pattern)
+			}
+			owners[key] = pattern
+			badgeMap[pattern].ID = pageID
+		}
+	}
+	return nil
+}
+
+// maxFileName is the longest file name most filesystems accept.
+const maxFileName = 255
+
+// redirects maps the page IDs badges may have had on earlier sites to their
+// current page IDs, so links to the old pages keep working. These are the
+// IDs unknown badges had before patterns were normalized, the pattern IDs of
+// badges since named in the user's catalog, and catalog IDs since made safe.
+// IDs that could not have been linked to, or that are current pages, are
+// left out. Where two badges claim an old ID, the first pattern wins.
+func redirects(badgeMap map[string]*badgeInfo) map[string]string {
+	pages := make(map[string]struct{}, len(badgeMap))
+	for _, info := range badgeMap {
+		pages[strings.ToLower(info.ID)] = struct{}{}
+	}
+	moved := make(map[string]string)
+	add := func(old, id string) {
+		if old == "" || strings.ContainsAny(old, `/\?#`) || len(old)+len(".html") > maxFileName {
+			return
+		}
+		if _, ok := pages[strings.ToLower(old)]; ok {
+			return
+		}
+		if _, ok := moved[old]; !ok {
+			moved[old] = id
+		}
+	}
+	for _, pattern := range slices.Sorted(maps.Keys(badgeMap)) {
+		info := badgeMap[pattern]
+		for _, old := range info.LegacyIDs {
+			add(old, info.ID)
+		}
+		if info.UserEntry {
+			add(catalog.PatternID(pattern), info.ID)
+			add(info.CatalogID, info.ID)
+		}
+	}
+	return moved
+}
+
+// legacyID returns the page ID an unknown badge had before patterns were
+// normalized, from its image URL and the repository it was found in.
+func legacyID(rawURL, org, repo string) string {
+	return strings.ReplaceAll(strings.ToLower(legacyPattern(rawURL, org, repo)), "/", "-")
+}
+
+// legacyPattern is the canonical pattern of a badge image URL as it was
+// computed before patterns were normalized: the first occurrences of the
+// organization and repository names were replaced, everything after the
+// repository name was dropped and the token query parameter was removed.
+func legacyPattern(rawURL, org, repo string) string {
+	result := rawURL
+	lowerURL := strings.ToLower(rawURL)
+
+	if idx := strings.Index(lowerURL, strings.ToLower(org)); idx != -1 {
+		result = result[:idx] + "{ORG}" + result[idx+len(org):]
+		lowerURL = strings.ToLower(result)
+	}
+	if idx := strings.Index(lowerURL, strings.ToLower(repo)); idx != -1 {
+		result = result[:idx] + "{REPO}/.*"
+	}
+
+	u, err := url.Parse(result)
+	if err == nil {
+		q := u.Query()
+		if q.Get("token") != "" {
+			q.Del("token")
+			if len(q) == 0 {
+				u.RawQuery = ""
+			} else {
+				u.RawQuery = q.Encode()
+			}
+			result = u.String()
+		}
+	}
+	return result
+}
@@ -0,0 +1,67 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/mirror"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+)
+
+// imagesDir is the output directory mirrored badge images are stored in.
+const imagesDir = "images"
+
+// mirrorImages downloads every badge image shown on the site into the
+// output directory and returns the template function resolving an image URL
+// to its local copy. Images that could not be mirrored keep their URL, and
+// hotlinked reports whether any did.
+func mirrorImages(outputDir, basePath string, repos []models.RepositoryData, org *models.OrganizationData, badgeMap map[string]*badgeInfo, opts mirror.Options) (image func(string) string, hotlinked bool, err error) {
+	var urls []string
+	for _, repo := range repos {
+		for _, b := range repo.Badges {
+			urls = append(urls, b.ImageURL)
+		}
+	}
+	if org != nil {
+		for _, b := range org.Badges {
+			urls = append(urls, b.ImageURL)
+		}
+	}
+	for _, info := range badgeMap {
+		urls = append(urls, info.SampleImage)
+	}
+	sort.Strings(urls)
+	urls = slices.Compact(urls)
+
+	files, summary, err := mirror.Run(context.Background(), urls, filepath.Join(outputDir, imagesDir), opts)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to mirror badge images: %w", err)
+	}
+	fmt.Printf("Mirrored %d badge images (%d downloaded, %d cached, %d stale, %d failed)\n",
+		summary.Images, summary.Downloaded, summary.Cached, summary.Stale, len(summary.Failed))
+	for _, u := range slices.Sorted(maps.Keys(summary.Failed)) {
+		fmt.Printf("  Linking %s directly: %s\n", u, summary.Failed[u])
+	}
+
+	return func(imageURL string) string {
+		if file, ok := files[imageURL]; ok {
+			return basePath + path.Join("/", imagesDir, file)
+		}
+		return siteImage(basePath, imageURL)
+	}, len(summary.Failed) > 0, nil
+}
+
+// siteImage serves site-relative image paths, such as catalog placeholders,
+// under the base path; other image URLs are left as they are.
+func siteImage(basePath, imageURL string) string {
+	if strings.HasPrefix(imageURL, "/") && !strings.HasPrefix(imageURL, "//") {
+		return basePath + imageURL
+	}
+	return imageURL
+}
@@ -1,20 +1,83 @@
 package generator
 
-import "github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+import (
+	"html/template"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+)
+
+// Site holds what every page needs to link to the rest of the site.
+type Site struct {
+	// BasePath is the path the site is served under, such as /project,
+	// empty at a domain root. Every internal link starts with it.
+	BasePath string
+	// HTMXURL is where pages load the vendored htmx from.
+	HTMXURL       string
+	HTMXIntegrity string
+	// CSP is the Content-Security-Policy pages declare, empty for none.
+	CSP string
+}
 
 // DashboardViewModel is used for the index page.
 type DashboardViewModel struct {
+	Site             *Site
 	OrgName          string
 	TotalRepos       int
 	TotalBadges      int
 	ReposWithBadges  int
 	ReposNoBadges    int
 	Repositories     []RepoSummary
+	HasStates        bool // whether any badge state is known, for the state filter
 	BadgesByCategory []BadgeCategory
 	UniqueBadgeCount int
+	Organization     *OrganizationSummary
+	Compliance       *ComplianceOverview // nil without a policy
+	Trend            template.HTML       // inline SVG chart, empty without history
 	LastUpdated      string
 }
 
+// ComplianceOverview summarizes the badge policy across repositories.
+type ComplianceOverview struct {
+	InScope           int
+	Compliant         int
+	NonCompliant      int
+	Rules             []RuleOverview
+	NonCompliantRepos []RepoCompliance
+}
+
+// RuleOverview counts the repositories passing and failing a policy rule.
+type RuleOverview struct {
+	Name        string
+	Description string
+	InScope     int
+	Passing     int
+	Failing     int
+}
+
+// RepoCompliance is a repository's result for the policy rules in scope.
+type RepoCompliance struct {
+	Repository string
+	Compliant  bool
+	Rules      []RuleCompliance
+}
+
+// RuleCompliance is the outcome of one policy rule for a repository.
+type RuleCompliance struct {
+	Name        string
+	Description string
+	Passed      bool
+	Problems    []string
+}
+
+// OrganizationSummary holds the badges from the organization profile README.
+type OrganizationSummary struct {
+	Name        string
+	ProfileURL  string
+	ReadmeURL   string
+	ReadmeFound bool
+	Badges      []RepoBadge
+}
+
 // BadgeCategory groups badges by category.
 type BadgeCategory struct {
 	Name   string
@@ -26,6 +89,12 @@ type RepoSummary struct {
 	Name       string
 	BadgeCount int
 	BadgeIDs   []string // IDs of badges this repo has, for filtering
+	// BadgeStates pairs badge IDs with the state their image shows, as
+	// "id:state", for filtering by state.
+	BadgeStates []string
+	// State is failing when any badge shows failing, passing when any
+	// shows passing, and empty otherwise.
+	State string
 }
 
 // BadgeSummary is a summary of a unique badge pattern.
@@ -46,13 +115,30 @@ type RepoBadge struct {
 	Name      string
 	Category  string
 	ID        string
+	Source    string
+	// Problem describes a broken image or link found by the last liveness
+	// check.
+	Problem string
+	// Text and State are what the image said at the last liveness check.
+	Text  string
+	State string
+}
+
+// RepoBadgeFile groups a repository's badges by the file they were found in.
+type RepoBadgeFile struct {
+	Path   string
+	URL    string
+	Badges []RepoBadge
 }
 
 // RepoPageViewModel is used for individual repository pages.
 type RepoPageViewModel struct {
+	Site        *Site
 	OrgName     string
 	Repository  models.RepositoryData
 	Badges      []RepoBadge
+	Files       []RepoBadgeFile
+	Compliance  *RepoCompliance // nil without a policy
 	LastUpdated string
 }
 
@@ -61,29 +147,28 @@ type BadgeRepoBadge struct {
 	RepoName  string
 	ImageURL  string
 	TargetURL string
+	Problem   string
+	Text      string
+	State     string
 }
 
 // BadgePageViewModel is used for individual badge pages.
 type BadgePageViewModel struct {
+	Site         *Site
 	OrgName      string
 	ImageURL     string
 	Pattern      string
 	Name         string
 	Category     string
 	Repositories []BadgeRepoBadge
+	Broken       int           // repositories whose badge is broken
+	Trend        template.HTML // inline SVG chart, empty without history
 	LastUpdated  string
 }
 
-// BadgeConfig represents the badges.json configuration.
-type BadgeConfig struct {
-	Badges []BadgeConfigEntry `json:"badges"`
-}
-
-// BadgeConfigEntry represents a single badge configuration entry.
-type BadgeConfigEntry struct {
-	ID          string `json:"id"`
-	Pattern     string `json:"pattern"`
-	Name        string `json:"name"`
-	Category    string `json:"category"`
-	Placeholder string `json:"placeholder,omitempty"`
+// RedirectViewModel is used for pages left at the former ID of a badge.
+type RedirectViewModel struct {
+	Site    *Site
+	OrgName string
+	ID      string
 }
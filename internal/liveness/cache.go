@@ -0,0 +1,86 @@
+package liveness
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+)
+
+// Cache keeps liveness results between runs, keyed by URL, so repeated
+// checks within MaxAge do not hit badge services again.
+type Cache struct {
+	mu      sync.Mutex
+	Entries map[string]models.LinkStatus `json:"entries"`
+}
+
+// LoadCache reads a cache file; a missing file is an empty cache.
+func LoadCache(path string) (*Cache, error) {
+	cache := &Cache{Entries: make(map[string]models.LinkStatus)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read liveness cache: %w", err)
+	}
+	if err := json.Unmarshal(data, cache); err != nil {
+		return nil, fmt.Errorf("failed to parse liveness cache %s: %w", path, err)
+	}
+	if cache.Entries == nil {
+		cache.Entries = make(map[string]models.LinkStatus)
+	}
+	return cache, nil
+}
+
+// Save writes the cache, leaving out entries older than maxAge, which would
+// never be used again.
+func (c *Cache) Save(path string, maxAge time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fresh := &Cache{Entries: make(map[string]models.LinkStatus, len(c.Entries))}
+	now := time.Now()
+	for u, status := range c.Entries {
+		if now.Sub(status.CheckedAt) <= maxAge {
+			fresh.Entries[u] = status
+		}
+	}
+	data, err := json.MarshalIndent(fresh, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode liveness cache: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write liveness cache: %w", err)
+	}
+	return nil
+}
+
+// get returns a result no older than maxAge. A nil cache has no results.
+func (c *Cache) get(u string, maxAge time.Duration, now time.Time) (models.LinkStatus, bool) {
+	if c == nil {
+		return models.LinkStatus{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status, ok := c.Entries[u]
+	if !ok || now.Sub(status.CheckedAt) > maxAge {
+		return models.LinkStatus{}, false
+	}
+	return status, true
+}
+
+// put records a result. A nil cache discards it.
+func (c *Cache) put(u string, status models.LinkStatus) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Entries[u] = status
+}
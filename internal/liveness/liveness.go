@@ -0,0 +1,210 @@
+// Package liveness fetches badge images and link targets to find badges that
+// render as broken images or lead nowhere.
+package liveness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/fetch"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+)
+
+// Options configures a liveness check.
+type Options struct {
+	// Client sends the requests; nil uses a client with Timeout.
+	Client *http.Client
+	// Concurrency bounds the requests in flight.
+	Concurrency int
+	// Timeout bounds each request, including redirects.
+	Timeout time.Duration
+	// Cache, when set, provides results younger than MaxAge instead of
+	// fetching them again, and receives every new result.
+	Cache  *Cache
+	MaxAge time.Duration
+}
+
+// Summary counts what a check did.
+type Summary struct {
+	// URLs is the number of unique URLs checked.
+	URLs    int
+	Fetched int
+	Cached  int
+	// BrokenImages and BrokenTargets count badges, not URLs.
+	BrokenImages  int
+	BrokenTargets int
+}
+
+// Check fetches every unique absolute http(s) badge image and target URL of
+// the crawl and records the results on its badges. Relative URLs are left
+// unchecked.
+func Check(ctx context.Context, bundle *models.Bundle, opts Options) Summary {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = fetch.DefaultConcurrency
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = fetch.DefaultTimeout
+	}
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: opts.Timeout}
+	}
+
+	badges := badgesOf(bundle)
+	var urls []string
+	seen := make(map[string]struct{})
+	for _, b := range badges {
+		for _, u := range []string{b.ImageURL, b.TargetURL} {
+			if _, ok := seen[u]; ok || !fetch.Fetchable(u) {
+				continue
+			}
+			seen[u] = struct{}{}
+			urls = append(urls, u)
+		}
+	}
+
+	summary := Summary{URLs: len(urls)}
+	results := make(map[string]models.LinkStatus, len(urls))
+	var pending []string
+	now := time.Now()
+	for _, u := range urls {
+		if status, ok := opts.Cache.get(u, opts.MaxAge, now); ok {
+			results[u] = status
+			summary.Cached++
+		} else {
+			pending = append(pending, u)
+		}
+	}
+	summary.Fetched = len(pending)
+
+	var mu sync.Mutex
+	fetch.Each(pending, opts.Concurrency, func(u string) {
+		status := request(ctx, client, opts.Timeout, u)
+		mu.Lock()
+		results[u] = status
+		mu.Unlock()
+		opts.Cache.put(u, status)
+	})
+
+	for _, b := range badges {
+		b.ImageStatus = lookup(results, b.ImageURL)
+		b.TargetStatus = lookup(results, b.TargetURL)
+		if ImageProblem(b.ImageStatus) != "" {
+			summary.BrokenImages++
+		}
+		if TargetProblem(b.TargetStatus) != "" {
+			summary.BrokenTargets++
+		}
+	}
+	return summary
+}
+
+// badgesOf returns pointers to every badge of the crawl, repositories first.
+func badgesOf(bundle *models.Bundle) []*models.Badge {
+	var badges []*models.Badge
+	for i := range bundle.Repositories {
+		for j := range bundle.Repositories[i].Badges {
+			badges = append(badges, &bundle.Repositories[i].Badges[j])
+		}
+	}
+	if bundle.Profile != nil {
+		for i := range bundle.Profile.Badges {
+			badges = append(badges, &bundle.Profile.Badges[i])
+		}
+	}
+	return badges
+}
+
+// lookup returns a copy of the result for a URL, or nil when it was not
+// checked.
+func lookup(results map[string]models.LinkStatus, u string) *models.LinkStatus {
+	status, ok := results[u]
+	if !ok {
+		return nil
+	}
+	return &status
+}
+
+// request requests a URL and records the response status and content type, or
+// why none arrived, and the text of SVG badges. Latency is measured to the
+// response headers; other bodies are not read.
+func request(ctx context.Context, client *http.Client, timeout time.Duration, u string) models.LinkStatus {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	status := models.LinkStatus{CheckedAt: start.UTC()}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	req.Header.Set("User-Agent", fetch.UserAgent)
+
+	resp, err := client.Do(req)
+	status.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		status.Error = describeError(err)
+		return status
+	}
+	defer resp.Body.Close()
+
+	status.StatusCode = resp.StatusCode
+	status.ContentType = resp.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(status.ContentType); err == nil {
+		status.ContentType = mediaType
+	}
+	if status.StatusCode < 400 && status.ContentType == "image/svg+xml" {
+		status.Label, status.Message = parseSVG(resp.Body)
+	}
+	return status
+}
+
+// describeError drops the request method and URL that client errors repeat.
+func describeError(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timed out"
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		if urlErr.Timeout() {
+			return "timed out"
+		}
+		return urlErr.Err.Error()
+	}
+	return err.Error()
+}
+
+// ImageProblem describes why a checked badge image does not render: no
+// response, an error status or a response that is not an image. It is empty
+// for working and unchecked images.
+func ImageProblem(status *models.LinkStatus) string {
+	if problem := TargetProblem(status); problem != "" {
+		return problem
+	}
+	if status != nil && status.ContentType != "" && !strings.HasPrefix(status.ContentType, "image/") {
+		return fmt.Sprintf("not an image (%s)", status.ContentType)
+	}
+	return ""
+}
+
+// TargetProblem describes why a checked badge link leads nowhere: no
+// response or an error status. It is empty for working and unchecked links.
+func TargetProblem(status *models.LinkStatus) string {
+	switch {
+	case status == nil:
+		return ""
+	case status.Error != "":
+		return status.Error
+	case status.StatusCode >= 400:
+		return fmt.Sprintf("HTTP %d %s", status.StatusCode, http.StatusText(status.StatusCode))
+	}
+	return ""
+}
@@ -0,0 +1,198 @@
+package liveness
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+)
+
+// badgeServer serves working, missing, non-image and slow badges, counting
+// requests per path and the most requests ever in flight.
+type badgeServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	hits     map[string]int
+	inFlight atomic.Int32
+	maxSeen  atomic.Int32
+}
+
+func newBadgeServer(t *testing.T) *badgeServer {
+	s := &badgeServer{hits: make(map[string]int)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+		for {
+			seen := s.maxSeen.Load()
+			if n <= seen || s.maxSeen.CompareAndSwap(seen, n) {
+				break
+			}
+		}
+		s.mu.Lock()
+		s.hits[r.URL.Path]++
+		s.mu.Unlock()
+
+		// Overlapping requests make the concurrency bound observable
+		time.Sleep(20 * time.Millisecond)
+		switch r.URL.Path {
+		case "/ok.svg":
+			w.Header().Set("Content-Type", "image/svg+xml; charset=utf-8")
+			w.Write([]byte(`<svg xmlns="http://www.w3.org/2000/svg" aria-label="build: passing"><title>build: passing</title></svg>`))
+		case "/page":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html></html>"))
+		case "/slow.svg":
+			select {
+			case <-r.Context().Done():
+			case <-time.After(2 * time.Second):
+			}
+		case "/moved.svg":
+			http.Redirect(w, r, "/ok.svg", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *badgeServer) hitCount(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits[path]
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	s := newBadgeServer(t)
+	bundle := &models.Bundle{
+		Repositories: []models.RepositoryData{
+			{Repository: "alpha", Badges: []models.Badge{
+				{ImageURL: s.URL + "/ok.svg", TargetURL: s.URL + "/page"},
+				{ImageURL: s.URL + "/missing.svg", TargetURL: s.URL + "/gone"},
+				{ImageURL: s.URL + "/page", TargetURL: "docs/README.md"},
+			}},
+			{Repository: "beta", Badges: []models.Badge{
+				{ImageURL: s.URL + "/ok.svg", TargetURL: s.URL + "/page"},
+				{ImageURL: s.URL + "/slow.svg"},
+				{ImageURL: s.URL + "/moved.svg"},
+			}},
+		},
+		Profile: &models.OrganizationData{Badges: []models.Badge{
+			{ImageURL: s.URL + "/ok.svg"},
+		}},
+	}
+	cache, err := LoadCache(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary := Check(context.Background(), bundle, Options{Concurrency: 2, Timeout: 200 * time.Millisecond, Cache: cache, MaxAge: time.Hour})
+
+	if summary.URLs != 6 || summary.Fetched != 6 || summary.Cached != 0 {
+		t.Errorf("summary = %+v, want 6 unique URLs fetched", summary)
+	}
+	if summary.BrokenImages != 3 || summary.BrokenTargets != 1 {
+		t.Errorf("summary = %+v, want 3 broken images and 1 broken target", summary)
+	}
+	if n := s.hitCount("/ok.svg"); n != 2 {
+		t.Errorf("/ok.svg fetched %d times, want once plus once through the redirect", n)
+	}
+	if n := s.maxSeen.Load(); n > 2 {
+		t.Errorf("%d requests in flight, want at most 2", n)
+	}
+
+	alpha, beta := bundle.Repositories[0].Badges, bundle.Repositories[1].Badges
+	tests := []struct {
+		name        string
+		status      *models.LinkStatus
+		problem     func(*models.LinkStatus) string
+		wantCode    int
+		wantType    string
+		wantProblem string
+	}{
+		{"working image", alpha[0].ImageStatus, ImageProblem, 200, "image/svg+xml", ""},
+		{"working target", alpha[0].TargetStatus, TargetProblem, 200, "text/html", ""},
+		{"missing image", alpha[1].ImageStatus, ImageProblem, 404, "text/plain", "HTTP 404 Not Found"},
+		{"missing target", alpha[1].TargetStatus, TargetProblem, 404, "text/plain", "HTTP 404 Not Found"},
+		{"page as image", alpha[2].ImageStatus, ImageProblem, 200, "text/html", "not an image (text/html)"},
+		{"slow image", beta[1].ImageStatus, ImageProblem, 0, "", "timed out"},
+		{"redirected image", beta[2].ImageStatus, ImageProblem, 200, "image/svg+xml", ""},
+		{"profile badge", bundle.Profile.Badges[0].ImageStatus, ImageProblem, 200, "image/svg+xml", ""},
+	}
+	for _, tt := range tests {
+		if tt.status == nil {
+			t.Errorf("%s: not checked", tt.name)
+			continue
+		}
+		if tt.status.StatusCode != tt.wantCode || tt.status.ContentType != tt.wantType {
+			t.Errorf("%s: status = %d %q, want %d %q", tt.name, tt.status.StatusCode, tt.status.ContentType, tt.wantCode, tt.wantType)
+		}
+		if got := tt.problem(tt.status); got != tt.wantProblem {
+			t.Errorf("%s: problem = %q, want %q", tt.name, got, tt.wantProblem)
+		}
+		if tt.status.CheckedAt.IsZero() {
+			t.Errorf("%s: CheckedAt not set", tt.name)
+		}
+	}
+	if got := alpha[0].ImageStatus; got.Label != "build" || got.Message != "passing" {
+		t.Errorf("SVG text = %q, %q, want build, passing", got.Label, got.Message)
+	}
+	if got := alpha[0].TargetStatus; got.Label != "" || got.Message != "" {
+		t.Errorf("HTML page text = %q, %q, want none", got.Label, got.Message)
+	}
+	if alpha[2].TargetStatus != nil || beta[1].TargetStatus != nil {
+		t.Error("relative and empty targets were checked")
+	}
+
+	// A second check is served from the cache
+	summary = Check(context.Background(), bundle, Options{Cache: cache, MaxAge: time.Hour})
+	if summary.Fetched != 0 || summary.Cached != 6 {
+		t.Errorf("cached summary = %+v, want every URL from the cache", summary)
+	}
+	if n := s.hitCount("/missing.svg"); n != 1 {
+		t.Errorf("/missing.svg fetched %d times, want once", n)
+	}
+	if alpha[1].ImageStatus == nil || alpha[1].ImageStatus.StatusCode != 404 {
+		t.Errorf("cached status = %+v, want 404", alpha[1].ImageStatus)
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "cache.json")
+	cache, err := LoadCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	cache.put("https://example.com/fresh.svg", models.LinkStatus{StatusCode: 200, CheckedAt: now.Add(-time.Minute)})
+	cache.put("https://example.com/stale.svg", models.LinkStatus{StatusCode: 200, CheckedAt: now.Add(-2 * time.Hour)})
+
+	if _, ok := cache.get("https://example.com/stale.svg", time.Hour, now); ok {
+		t.Error("get() returned a stale result")
+	}
+	if err := cache.Save(path, time.Hour); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	loaded, err := LoadCache(path)
+	if err != nil {
+		t.Fatalf("LoadCache() error = %v", err)
+	}
+	if _, ok := loaded.get("https://example.com/fresh.svg", time.Hour, now); !ok || len(loaded.Entries) != 1 {
+		t.Errorf("loaded cache = %+v, want only the fresh result", loaded.Entries)
+	}
+
+	var none *Cache
+	if _, ok := none.get("https://example.com/fresh.svg", time.Hour, now); ok {
+		t.Error("nil cache returned a result")
+	}
+}
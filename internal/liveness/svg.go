@@ -0,0 +1,141 @@
+package liveness
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+)
+
+// maxSVGSize bounds the badge images read for their text; badges are a few
+// kilobytes.
+const maxSVGSize = 256 << 10
+
+// Badge states derived from badge messages.
+const (
+	StatePassing = "passing"
+	StateFailing = "failing"
+)
+
+// passingWords and failingWords are the message words that decide a state.
+var (
+	passingWords = map[string]struct{}{
+		"passing": {}, "passed": {}, "pass": {}, "success": {}, "succeeded": {},
+		"ok": {}, "healthy": {}, "up": {}, "online": {}, "operational": {},
+	}
+	failingWords = map[string]struct{}{
+		"failing": {}, "failed": {}, "fail": {}, "failure": {}, "error": {},
+		"errored": {}, "broken": {}, "down": {}, "offline": {}, "critical": {},
+	}
+)
+
+// parseSVG extracts the label and message of a shields-style badge: two
+// text areas such as "build | passing" or "coverage | 73%". The accessible
+// name on the root element is preferred, then the title, then the visible
+// text. A badge with a single text area has only a message.
+func parseSVG(r io.Reader) (label, message string) {
+	var ariaLabel, title string
+	var texts []string
+	var inTitle, inText bool
+	var current strings.Builder
+
+	decoder := xml.NewDecoder(io.LimitReader(r, maxSVGSize))
+	decoder.Strict = false
+	for depth := 0; ; {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			switch {
+			case depth == 1 && t.Name.Local == "svg":
+				ariaLabel = attr(t, "aria-label")
+			case t.Name.Local == "title" && title == "":
+				inTitle = true
+			case t.Name.Local == "text":
+				inText = true
+				current.Reset()
+			}
+		case xml.EndElement:
+			depth--
+			switch t.Name.Local {
+			case "title":
+				inTitle = false
+			case "text":
+				inText = false
+				text := strings.TrimSpace(current.String())
+				// Shields draws every text twice, as a shadow and on top
+				if text != "" && (len(texts) == 0 || texts[len(texts)-1] != text) {
+					texts = append(texts, text)
+				}
+			}
+		case xml.CharData:
+			switch {
+			case inText:
+				current.Write(t)
+			case inTitle:
+				title += string(t)
+			}
+		}
+	}
+
+	for _, name := range []string{ariaLabel, title} {
+		if label, message, ok := splitName(strings.TrimSpace(name)); ok {
+			return label, message
+		}
+	}
+	switch len(texts) {
+	case 0:
+		return "", ""
+	case 1:
+		return "", texts[0]
+	default:
+		return texts[0], texts[len(texts)-1]
+	}
+}
+
+// splitName splits an accessible name such as "build: passing" or GitHub's
+// "CI - passing" into label and message.
+func splitName(name string) (label, message string, ok bool) {
+	for _, sep := range []string{": ", " - "} {
+		if label, message, ok := strings.Cut(name, sep); ok && label != "" && message != "" {
+			return strings.TrimSpace(label), strings.TrimSpace(message), true
+		}
+	}
+	return "", "", false
+}
+
+func attr(element xml.StartElement, name string) string {
+	for _, a := range element.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// State classifies the message of a checked badge image as StatePassing or
+// StateFailing. It is empty for other messages, such as coverage
+// percentages or versions, and for images without a parsed message.
+func State(status *models.LinkStatus) string {
+	if status == nil {
+		return ""
+	}
+	words := strings.FieldsFunc(strings.ToLower(status.Message), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z')
+	})
+	for _, word := range words {
+		if _, ok := failingWords[word]; ok {
+			return StateFailing
+		}
+	}
+	for _, word := range words {
+		if _, ok := passingWords[word]; ok {
+			return StatePassing
+		}
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+package liveness
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+)
+
+func TestParseSVG(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		svg         string
+		wantLabel   string
+		wantMessage string
+	}{
+		{
+			"shields accessible name",
+			`<svg xmlns="http://www.w3.org/2000/svg" width="88" height="20" role="img" aria-label="build: passing"><title>build: passing</title>` +
+				`<g><text aria-hidden="true" fill-opacity=".3">build</text><text>build</text>` +
+				`<text aria-hidden="true" fill-opacity=".3">passing</text><text>passing</text></g></svg>`,
+			"build", "passing",
+		},
+		{
+			"message with separator",
+			`<svg aria-label="license: Apache-2.0: with exceptions"></svg>`,
+			"license", "Apache-2.0: with exceptions",
+		},
+		{
+			"github actions title",
+			`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"><title>CI - failing</title><g><text>CI</text><text>failing</text></g></svg>`,
+			"CI", "failing",
+		},
+		{
+			"shadowed texts without a name",
+			`<svg xmlns="http://www.w3.org/2000/svg"><g><text fill-opacity=".3">coverage</text><text>coverage</text>` +
+				`<text fill-opacity=".3">73%</text><text>73%</text></g></svg>`,
+			"coverage", "73%",
+		},
+		{
+			"text in spans",
+			`<svg><text><tspan>go</tspan> <tspan>report</tspan></text><text>A+</text></svg>`,
+			"go report", "A+",
+		},
+		{
+			"single text area",
+			`<svg aria-label="stable"><text>stable</text></svg>`,
+			"", "stable",
+		},
+		{
+			"no text",
+			`<svg><rect width="10" height="10"/></svg>`,
+			"", "",
+		},
+		{
+			"not xml",
+			`GIF89a`,
+			"", "",
+		},
+		{
+			"truncated",
+			`<svg aria-label="docs: latest"><g><text>docs`,
+			"docs", "latest",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			label, message := parseSVG(strings.NewReader(tt.svg))
+			if label != tt.wantLabel || message != tt.wantMessage {
+				t.Errorf("parseSVG() = %q, %q, want %q, %q", label, message, tt.wantLabel, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestState(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"passing", StatePassing},
+		{"Passing", StatePassing},
+		{"build succeeded", StatePassing},
+		{"failing", StateFailing},
+		{"tests failed", StateFailing},
+		{"1 passed, 2 failed", StateFailing},
+		{"no status", ""},
+		{"73%", ""},
+		{"v1.2.3", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := State(&models.LinkStatus{Message: tt.message}); got != tt.want {
+			t.Errorf("State(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+	if got := State(nil); got != "" {
+		t.Errorf("State(nil) = %q, want empty", got)
+	}
+}
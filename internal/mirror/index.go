@@ -0,0 +1,112 @@
+package mirror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// IndexFile is the name of the cache index in the cache directory.
+const IndexFile = "index.json"
+
+// cachedImage matches the content-hash names of cached images, so pruning
+// leaves any other file in the cache directory alone.
+var cachedImage = regexp.MustCompile(`^[0-9a-f]{16}\.[a-z]+$`)
+
+// index maps image URLs to the cached files holding them.
+type index struct {
+	mu      sync.Mutex
+	Entries map[string]entry `json:"entries"`
+}
+
+// entry is a cached image and the validators to revalidate it with.
+type entry struct {
+	File         string    `json:"file"`
+	ContentType  string    `json:"content_type"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// loadIndex reads the index of a cache directory, creating the directory
+// when needed; a missing index is an empty cache.
+func loadIndex(dir string) (*index, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create image cache %s: %w", dir, err)
+	}
+	idx := &index{Entries: make(map[string]entry)}
+	data, err := os.ReadFile(filepath.Join(dir, IndexFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image cache: %w", err)
+	}
+	if err := json.Unmarshal(data, idx); err != nil {
+		return nil, fmt.Errorf("failed to parse image cache %s: %w", filepath.Join(dir, IndexFile), err)
+	}
+	if idx.Entries == nil {
+		idx.Entries = make(map[string]entry)
+	}
+	return idx, nil
+}
+
+// save writes the index, leaving out entries for URLs that were not
+// requested this run and are older than maxAge, and deletes the cached
+// images no remaining entry refers to, so the cache does not grow with
+// every badge that changed or disappeared.
+func (idx *index) save(dir string, requested map[string]struct{}, maxAge time.Duration) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	now := time.Now()
+	used := make(map[string]struct{}, len(idx.Entries))
+	for u, e := range idx.Entries {
+		if _, ok := requested[u]; !ok && now.Sub(e.FetchedAt) > maxAge {
+			delete(idx.Entries, u)
+			continue
+		}
+		used[e.File] = struct{}{}
+	}
+
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode image cache: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, IndexFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write image cache: %w", err)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read image cache: %w", err)
+	}
+	for _, f := range files {
+		if _, ok := used[f.Name()]; ok || !cachedImage.MatchString(f.Name()) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, f.Name())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to prune image cache: %w", err)
+		}
+	}
+	return nil
+}
+
+func (idx *index) get(u string) (entry, bool) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	e, ok := idx.Entries[u]
+	return e, ok
+}
+
+func (idx *index) put(u string, e entry) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.Entries[u] = e
+}
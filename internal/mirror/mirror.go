@@ -0,0 +1,286 @@
+// Package mirror downloads badge images so the generated site can serve
+// local copies instead of hotlinking badge services.
+package mirror
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/fetch"
+)
+
+// maxImageSize bounds a downloaded image.
+const maxImageSize = 5 << 20
+
+// extensions maps image media types to file extensions.
+var extensions = map[string]string{
+	"image/svg+xml":            ".svg",
+	"image/png":                ".png",
+	"image/gif":                ".gif",
+	"image/jpeg":               ".jpg",
+	"image/webp":               ".webp",
+	"image/avif":               ".avif",
+	"image/x-icon":             ".ico",
+	"image/vnd.microsoft.icon": ".ico",
+}
+
+// Options configures a mirror run.
+type Options struct {
+	// Client sends the requests; nil uses a client with Timeout.
+	Client *http.Client
+	// CacheDir keeps downloaded images and their index between runs. Without
+	// it every image is downloaded again. Images not requested for longer
+	// than MaxAge are removed from it.
+	CacheDir string
+	// MaxAge is how long a cached image is used without asking the badge
+	// service whether it changed.
+	MaxAge time.Duration
+	// Concurrency bounds the requests in flight.
+	Concurrency int
+	// Timeout bounds each request, including redirects.
+	Timeout time.Duration
+}
+
+// Summary counts what a mirror run did.
+type Summary struct {
+	Images     int
+	Downloaded int
+	// Cached counts images served from the cache, whether fresh or
+	// confirmed unchanged by the badge service.
+	Cached int
+	// Stale counts images that could not be refreshed, served from an
+	// older cached copy.
+	Stale int
+	// Failed maps the images that could not be mirrored to the reason.
+	Failed map[string]string
+}
+
+// Run mirrors the absolute http(s) image URLs into dir and returns the file
+// name of each mirrored URL, relative to dir. Files are named by a hash of
+// their content, so unchanged images keep their name across runs and
+// identical images share a file. URLs that could not be mirrored are left
+// out and reported in the summary.
+func Run(ctx context.Context, urls []string, dir string, opts Options) (map[string]string, Summary, error) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = fetch.DefaultConcurrency
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = fetch.DefaultTimeout
+	}
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: opts.Timeout}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, Summary{}, fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	// Without a cache, images are stored straight into dir
+	store := dir
+	idx := &index{Entries: make(map[string]entry)}
+	if opts.CacheDir != "" {
+		store = opts.CacheDir
+		var err error
+		if idx, err = loadIndex(store); err != nil {
+			return nil, Summary{}, err
+		}
+	}
+
+	var pending []string
+	seen := make(map[string]struct{})
+	for _, u := range urls {
+		if _, ok := seen[u]; ok || !fetch.Fetchable(u) {
+			continue
+		}
+		seen[u] = struct{}{}
+		pending = append(pending, u)
+	}
+
+	summary := Summary{Images: len(pending), Failed: make(map[string]string)}
+	files := make(map[string]string, len(pending))
+	var mu sync.Mutex
+	fetch.Each(pending, opts.Concurrency, func(u string) {
+		file, outcome, err := idx.fetch(ctx, client, opts, store, u)
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case err != nil:
+			summary.Failed[u] = err.Error()
+		case outcome == outcomeDownloaded:
+			summary.Downloaded++
+		case outcome == outcomeStale:
+			summary.Stale++
+		default:
+			summary.Cached++
+		}
+		if err == nil {
+			files[u] = file
+		}
+	})
+
+	if opts.CacheDir != "" {
+		if err := idx.save(store, seen, opts.MaxAge); err != nil {
+			return nil, summary, err
+		}
+		for _, file := range files {
+			if err := copyFile(filepath.Join(store, file), filepath.Join(dir, file)); err != nil {
+				return nil, summary, err
+			}
+		}
+	}
+	return files, summary, nil
+}
+
+// outcome is how fetch obtained an image.
+type outcome int
+
+const (
+	outcomeDownloaded outcome = iota
+	outcomeCached
+	outcomeStale
+)
+
+// fetch returns the stored file for a URL, downloading it unless a fresh
+// copy is cached. A stale copy is revalidated with the validators the badge
+// service sent, and still used when the refresh fails.
+func (idx *index) fetch(ctx context.Context, client *http.Client, opts Options, store, u string) (string, outcome, error) {
+	cached, ok := idx.get(u)
+	if ok {
+		if _, err := os.Stat(filepath.Join(store, cached.File)); err != nil {
+			ok = false
+		}
+	}
+	if ok && time.Since(cached.FetchedAt) < opts.MaxAge {
+		return cached.File, outcomeCached, nil
+	}
+
+	file, notModified, e, err := download(ctx, client, opts.Timeout, store, u, cached, ok)
+	if err != nil {
+		if ok {
+			return cached.File, outcomeStale, nil
+		}
+		return "", 0, err
+	}
+	if notModified {
+		cached.FetchedAt = time.Now().UTC()
+		idx.put(u, cached)
+		return cached.File, outcomeCached, nil
+	}
+	idx.put(u, e)
+	return file, outcomeDownloaded, nil
+}
+
+// download requests an image, conditionally when a cached copy exists, and
+// stores it under its content hash. SVGs are sanitized first.
+func download(ctx context.Context, client *http.Client, timeout time.Duration, store, u string, cached entry, conditional bool) (file string, notModified bool, e entry, err error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", false, entry{}, err
+	}
+	req.Header.Set("User-Agent", fetch.UserAgent)
+	if conditional {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return "", false, entry{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && conditional {
+		return cached.File, true, cached, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", false, entry{}, fmt.Errorf("HTTP %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	ext, ok := extensions[contentType]
+	if !ok {
+		return "", false, entry{}, fmt.Errorf("not an image (%s)", contentType)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return "", false, entry{}, fmt.Errorf("failed to read image: %w", err)
+	}
+	if len(data) > maxImageSize {
+		return "", false, entry{}, fmt.Errorf("image larger than %d bytes", maxImageSize)
+	}
+	if contentType == "image/svg+xml" {
+		if data, err = sanitizeSVG(data); err != nil {
+			return "", false, entry{}, err
+		}
+	}
+
+	sum := sha256.Sum256(data)
+	file = hex.EncodeToString(sum[:])[:16] + ext
+	if err := writeFileAtomic(filepath.Join(store, file), data); err != nil {
+		return "", false, entry{}, err
+	}
+	return file, false, entry{
+		File:         file,
+		ContentType:  contentType,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    time.Now().UTC(),
+	}, nil
+}
+
+// writeFileAtomic writes through a temporary file, so concurrent writers of
+// the same content-named file never leave it partly written.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".image-*")
+	if err != nil {
+		return fmt.Errorf("failed to store image: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to store image: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to store image: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to store image: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store image: %w", err)
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("failed to read cached image: %w", err)
+	}
+	if err := os.WriteFile(dst, data, 0644); err != nil {
+		return fmt.Errorf("failed to write image: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+package mirror
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// imageServer serves badge images with ETags and counts full responses and
+// not-modified responses per path.
+type imageServer struct {
+	*httptest.Server
+	mu          sync.Mutex
+	served      map[string]int
+	notModified map[string]int
+	body        string
+}
+
+func newImageServer(t *testing.T) *imageServer {
+	s := &imageServer{served: make(map[string]int), notModified: make(map[string]int), body: `<svg xmlns="http://www.w3.org/2000/svg"/>`}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		switch r.URL.Path {
+		case "/a.svg", "/b.svg":
+			etag := `"` + s.body + `"`
+			if r.Header.Get("If-None-Match") == etag {
+				s.notModified[r.URL.Path]++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			s.served[r.URL.Path]++
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Content-Type", "image/svg+xml; charset=utf-8")
+			w.Write([]byte(s.body))
+		case "/evil.svg":
+			w.Header().Set("Content-Type", "image/svg+xml")
+			w.Write([]byte(maliciousSVG))
+		case "/broken.svg":
+			w.Header().Set("Content-Type", "image/svg+xml")
+			w.Write([]byte(`<svg><script>alert(1)</script>`))
+		case "/c.png":
+			s.served[r.URL.Path]++
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("\x89PNG"))
+		case "/page":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *imageServer) counts(path string) (served, notModified int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.served[path], s.notModified[path]
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	s := newImageServer(t)
+	cache := t.TempDir()
+	urls := []string{
+		s.URL + "/a.svg",
+		s.URL + "/b.svg",
+		s.URL + "/a.svg",
+		s.URL + "/c.png",
+		s.URL + "/page",
+		s.URL + "/missing.svg",
+		"docs/badge.svg",
+		"data:image/svg+xml;base64,PHN2Zy8+",
+	}
+	opts := Options{CacheDir: cache, MaxAge: time.Hour, Concurrency: 2}
+
+	out := t.TempDir()
+	files, summary, err := Run(context.Background(), urls, out, opts)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if summary.Images != 5 || summary.Downloaded != 3 || summary.Cached != 0 || len(summary.Failed) != 2 {
+		t.Errorf("summary = %+v, want 5 images, 3 downloaded and 2 failed", summary)
+	}
+	if got := summary.Failed[s.URL+"/page"]; got != "not an image (text/html)" {
+		t.Errorf("Failed[page] = %q, want not an image", got)
+	}
+	if got := summary.Failed[s.URL+"/missing.svg"]; got != "HTTP 404 Not Found" {
+		t.Errorf("Failed[missing] = %q, want HTTP 404", got)
+	}
+	if len(files) != 3 {
+		t.Fatalf("files = %v, want the three images", files)
+	}
+	// Identical images share one content-named file
+	a, b, c := files[s.URL+"/a.svg"], files[s.URL+"/b.svg"], files[s.URL+"/c.png"]
+	if a != b || filepath.Ext(a) != ".svg" || filepath.Ext(c) != ".png" || a == c {
+		t.Errorf("files = %v, want a.svg and b.svg to share an .svg file and c.png its own .png", files)
+	}
+	for _, file := range []string{a, c} {
+		if _, err := os.Stat(filepath.Join(out, file)); err != nil {
+			t.Errorf("output is missing %s: %v", file, err)
+		}
+	}
+
+	// Fresh cache entries are reused without any request
+	out = t.TempDir()
+	files2, summary, err := Run(context.Background(), urls, out, opts)
+	if err != nil {
+		t.Fatalf("second Run() error = %v", err)
+	}
+	if summary.Cached != 3 || summary.Downloaded != 0 {
+		t.Errorf("second summary = %+v, want 3 cached", summary)
+	}
+	if served, _ := s.counts("/a.svg"); served != 1 {
+		t.Errorf("a.svg served %d times, want 1", served)
+	}
+	if files2[s.URL+"/a.svg"] != a {
+		t.Errorf("cached file = %q, want %q", files2[s.URL+"/a.svg"], a)
+	}
+	if _, err := os.Stat(filepath.Join(out, a)); err != nil {
+		t.Errorf("cached image not copied to the output: %v", err)
+	}
+
+	// Stale entries are revalidated with their ETag
+	opts.MaxAge = 0
+	if _, summary, err = Run(context.Background(), urls, t.TempDir(), opts); err != nil {
+		t.Fatalf("third Run() error = %v", err)
+	}
+	if summary.Cached != 2 || summary.Downloaded != 1 {
+		t.Errorf("third summary = %+v, want 2 revalidated and the PNG without an ETag downloaded", summary)
+	}
+	if served, notModified := s.counts("/a.svg"); served != 1 || notModified != 1 {
+		t.Errorf("a.svg served %d and not modified %d times, want 1 and 1", served, notModified)
+	}
+
+	// A changed image gets a new file
+	s.mu.Lock()
+	s.body = `<svg xmlns="http://www.w3.org/2000/svg"><text>new</text></svg>`
+	s.mu.Unlock()
+	files4, _, err := Run(context.Background(), urls, t.TempDir(), opts)
+	if err != nil {
+		t.Fatalf("fourth Run() error = %v", err)
+	}
+	if files4[s.URL+"/a.svg"] == a {
+		t.Errorf("changed image kept file %q", a)
+	}
+}
+
+func TestRunStaleFallback(t *testing.T) {
+	t.Parallel()
+
+	s := newImageServer(t)
+	cache := t.TempDir()
+	url := s.URL + "/a.svg"
+	files, _, err := Run(context.Background(), []string{url}, t.TempDir(), Options{CacheDir: cache})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// Once the service is gone, the stale copy is still served
+	s.Close()
+	out := t.TempDir()
+	again, summary, err := Run(context.Background(), []string{url}, out, Options{CacheDir: cache, Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("second Run() error = %v", err)
+	}
+	if summary.Stale != 1 || again[url] != files[url] {
+		t.Errorf("second Run() = %v, %+v, want the stale copy", again, summary)
+	}
+	if _, err := os.Stat(filepath.Join(out, files[url])); err != nil {
+		t.Errorf("stale image not copied to the output: %v", err)
+	}
+}
+
+func TestRunWithoutCache(t *testing.T) {
+	t.Parallel()
+
+	s := newImageServer(t)
+	out := t.TempDir()
+	files, summary, err := Run(context.Background(), []string{s.URL + "/c.png"}, out, Options{})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if summary.Downloaded != 1 {
+		t.Errorf("summary = %+v, want 1 downloaded", summary)
+	}
+	if _, err := os.Stat(filepath.Join(out, files[s.URL+"/c.png"])); err != nil {
+		t.Errorf("image not written to the output: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(out, IndexFile)); err == nil {
+		t.Errorf("output has a cache index without a cache directory")
+	}
+}
+
+// TestRunSanitizesSVG checks that only sanitized SVGs reach the site.
+func TestRunSanitizesSVG(t *testing.T) {
+	t.Parallel()
+
+	s := newImageServer(t)
+	out := t.TempDir()
+	files, summary, err := Run(context.Background(), []string{s.URL + "/evil.svg", s.URL + "/broken.svg"}, out, Options{})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if summary.Failed[s.URL+"/broken.svg"] == "" {
+		t.Errorf("summary = %+v, want broken.svg refused", summary)
+	}
+	if _, ok := files[s.URL+"/broken.svg"]; ok {
+		t.Errorf("files = %v, want broken.svg left out", files)
+	}
+	data, err := os.ReadFile(filepath.Join(out, files[s.URL+"/evil.svg"]))
+	if err != nil {
+		t.Fatalf("evil.svg not mirrored: %v", err)
+	}
+	if strings.Contains(string(data), "alert") || !strings.Contains(string(data), "build") {
+		t.Errorf("mirrored evil.svg is not sanitized:\n%s", data)
+	}
+}
+
+// TestRunPrunesCache checks that images no longer requested or replaced by
+// new content leave the cache once older than MaxAge.
+func TestRunPrunesCache(t *testing.T) {
+	t.Parallel()
+
+	s := newImageServer(t)
+	cache := t.TempDir()
+	if err := os.WriteFile(filepath.Join(cache, "notes.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	run := func(urls []string, maxAge time.Duration) map[string]string {
+		t.Helper()
+		files, _, err := Run(context.Background(), urls, t.TempDir(), Options{CacheDir: cache, MaxAge: maxAge})
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		return files
+	}
+	exists := func(file string) bool {
+		_, err := os.Stat(filepath.Join(cache, file))
+		return err == nil
+	}
+
+	first := run([]string{s.URL + "/a.svg", s.URL + "/c.png"}, time.Hour)
+	oldA, c := first[s.URL+"/a.svg"], first[s.URL+"/c.png"]
+
+	// Recently fetched images are kept while unrequested
+	run([]string{s.URL + "/a.svg"}, time.Hour)
+	if !exists(c) {
+		t.Errorf("c.png pruned before it reached MaxAge")
+	}
+
+	s.mu.Lock()
+	s.body = `<svg xmlns="http://www.w3.org/2000/svg"><text>new</text></svg>`
+	s.mu.Unlock()
+	newA := run([]string{s.URL + "/a.svg"}, 0)[s.URL+"/a.svg"]
+	if newA == oldA || !exists(newA) {
+		t.Errorf("a.svg = %q, want new content stored", newA)
+	}
+	if exists(oldA) || exists(c) {
+		t.Errorf("cache keeps %s (old a.svg) or %s (unrequested c.png)", oldA, c)
+	}
+	if !exists("notes.txt") || !exists(IndexFile) {
+		t.Errorf("pruning removed files it does not own")
+	}
+
+	idx, err := loadIndex(cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := idx.Entries[s.URL+"/c.png"]; ok || len(idx.Entries) != 1 {
+		t.Errorf("index = %+v, want only a.svg", idx.Entries)
+	}
+}
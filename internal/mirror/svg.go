@@ -0,0 +1,156 @@
+package mirror
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Namespaces kept in sanitized SVGs.
+const (
+	svgNamespace   = "http://www.w3.org/2000/svg"
+	xlinkNamespace = "http://www.w3.org/1999/xlink"
+	xmlNamespace   = "http://www.w3.org/XML/1998/namespace"
+)
+
+// textEscaper escapes character data, keeping line breaks as they are.
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// unsafeElements can run script or embed other documents; they are dropped
+// with their content. Names are compared in lower case.
+var unsafeElements = map[string]struct{}{
+	"script": {}, "foreignobject": {}, "iframe": {}, "frame": {}, "object": {},
+	"embed": {}, "handler": {}, "listener": {},
+}
+
+// sanitizeSVG rewrites a third-party SVG so it is safe to serve from the
+// site's own origin, where a script in it would run with the site's
+// privileges when the image is opened directly. Only SVG elements and
+// attributes, and XLink and XML attributes, are kept; unsafe elements,
+// event handler attributes, animations of links and links to anything but
+// a fragment or an embedded image are dropped, as are comments, processing
+// instructions and doctypes. Images that are not well-formed SVG are
+// refused.
+func sanitizeSVG(data []byte) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var out bytes.Buffer
+	depth, skip := 0, 0
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid SVG: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			if skip > 0 {
+				skip++
+				continue
+			}
+			if depth == 1 && out.Len() > 0 {
+				return nil, errors.New("invalid SVG: more than one root element")
+			}
+			if depth == 1 && !isSVGElement(t.Name, "svg") {
+				return nil, errors.New("invalid SVG: root element is not svg")
+			}
+			if !safeElement(t) {
+				skip = 1
+				continue
+			}
+			out.WriteString("<" + t.Name.Local)
+			if depth == 1 {
+				out.WriteString(` xmlns="` + svgNamespace + `" xmlns:xlink="` + xlinkNamespace + `"`)
+			}
+			for _, a := range t.Attr {
+				if name, ok := safeAttr(a); ok {
+					out.WriteString(" " + name + `="`)
+					xml.EscapeText(&out, []byte(a.Value))
+					out.WriteString(`"`)
+				}
+			}
+			out.WriteString(">")
+		case xml.EndElement:
+			depth--
+			if skip > 0 {
+				skip--
+				continue
+			}
+			out.WriteString("</" + t.Name.Local + ">")
+		case xml.CharData:
+			if skip == 0 && depth > 0 {
+				textEscaper.WriteString(&out, string(t))
+			}
+		}
+	}
+	if out.Len() == 0 {
+		return nil, errors.New("invalid SVG: no root element")
+	}
+	return out.Bytes(), nil
+}
+
+// isSVGElement reports whether name is the SVG element local. SVGs that
+// omit the namespace are treated as SVG.
+func isSVGElement(name xml.Name, local string) bool {
+	return (name.Space == svgNamespace || name.Space == "") && name.Local == local
+}
+
+// safeElement reports whether an element is kept: an SVG element that can
+// neither run script nor turn a link into one.
+func safeElement(t xml.StartElement) bool {
+	if t.Name.Space != svgNamespace && t.Name.Space != "" {
+		return false
+	}
+	if _, ok := unsafeElements[strings.ToLower(t.Name.Local)]; ok {
+		return false
+	}
+	// Animating a link can set it to a javascript: URL
+	for _, a := range t.Attr {
+		if a.Name.Local == "attributeName" && strings.HasSuffix(strings.ToLower(a.Value), "href") {
+			return false
+		}
+	}
+	return true
+}
+
+// safeAttr returns the name an attribute is written with, or false when it
+// is dropped. Namespace declarations are dropped; the root element declares
+// the namespaces that are kept.
+func safeAttr(a xml.Attr) (string, bool) {
+	var name string
+	switch a.Name.Space {
+	case "":
+		name = a.Name.Local
+	case xlinkNamespace:
+		name = "xlink:" + a.Name.Local
+	case xmlNamespace, "xml":
+		name = "xml:" + a.Name.Local
+	default:
+		return "", false
+	}
+	lower := strings.ToLower(a.Name.Local)
+	switch {
+	case a.Name.Local == "xmlns" && a.Name.Space == "":
+		return "", false
+	case strings.HasPrefix(lower, "on"):
+		return "", false
+	case lower == "href" && !safeLink(a.Value):
+		return "", false
+	case strings.Contains(strings.ToLower(strings.Join(strings.Fields(a.Value), "")), "javascript:"):
+		return "", false
+	}
+	return name, true
+}
+
+// safeLink reports whether a link stays within the image: a fragment or an
+// embedded image.
+func safeLink(value string) bool {
+	value = strings.TrimSpace(value)
+	return strings.HasPrefix(value, "#") || strings.HasPrefix(strings.ToLower(value), "data:image/")
+}
@@ -0,0 +1,86 @@
+package mirror
+
+import (
+	"strings"
+	"testing"
+)
+
+// maliciousSVG renders a badge but tries every way of running script on
+// the origin it is served from.
+const maliciousSVG = `<?xml version="1.0"?>
+<?xml-stylesheet href="https://evil.example/x.xsl" type="text/xsl"?>
+<!DOCTYPE svg PUBLIC "-//W3C//DTD SVG 1.1//EN" "http://www.w3.org/Graphics/SVG/1.1/DTD/svg11.dtd">
+<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" xmlns:h="http://www.w3.org/1999/xhtml" width="90" height="20" onload="alert(1)">
+<!-- <script>alert(2)</script> -->
+<script>alert(3)</script>
+<SCRIPT type="text/ecmascript">alert(4)</SCRIPT>
+<foreignObject><iframe xmlns="http://www.w3.org/1999/xhtml" src="javascript:alert(5)"></iframe></foreignObject>
+<h:script>alert(6)</h:script>
+<a href="javascript:alert(7)"><text x="5" y="14">build</text></a>
+<a xlink:href=" JaVaScRiPt:alert(8)"><text>link</text></a>
+<a href="https://evil.example/"><rect width="1" height="1"/></a>
+<a><set attributeName="href" to="javascript:alert(9)"/><text>set</text></a>
+<animate xlink:href="#r" attributeName="xlink:href" values="#r;javascript:alert(10)"/>
+<rect id="r" width="10" height="10" fill="url(#g)" style="fill: java&#x9;script:alert(11)" onclick="alert(12)" OnMouseOver="alert(13)"/>
+<use xlink:href="#r" x="20"/>
+<image href="data:image/png;base64,iVBORw0KGgo=" width="1" height="1"/>
+<text xml:space="preserve" x="50" y="14">passing &amp; &lt;ok&gt;</text>
+</svg>`
+
+func TestSanitizeSVG(t *testing.T) {
+	t.Parallel()
+
+	got, err := sanitizeSVG([]byte(maliciousSVG))
+	if err != nil {
+		t.Fatalf("sanitizeSVG() error = %v", err)
+	}
+	out := string(got)
+	for _, unsafe := range []string{
+		"script", "alert", "onload", "onclick", "OnMouseOver", "foreignObject", "iframe",
+		"xhtml", "evil.example", "<set", "<animate", "DOCTYPE", "<!--", "<?",
+	} {
+		if strings.Contains(strings.ToLower(out), strings.ToLower(unsafe)) {
+			t.Errorf("sanitized SVG contains %q:\n%s", unsafe, out)
+		}
+	}
+	for _, kept := range []string{
+		`<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="90" height="20">`,
+		`<text x="5" y="14">build</text>`,
+		`<rect id="r" width="10" height="10" fill="url(#g)"></rect>`,
+		`<use xlink:href="#r" x="20"></use>`,
+		`<image href="data:image/png;base64,iVBORw0KGgo=" width="1" height="1"></image>`,
+		`<text xml:space="preserve" x="50" y="14">passing &amp; &lt;ok&gt;</text>`,
+	} {
+		if !strings.Contains(out, kept) {
+			t.Errorf("sanitized SVG lacks %s:\n%s", kept, out)
+		}
+	}
+
+	// Sanitizing is stable, so sanitized images keep their content hash
+	again, err := sanitizeSVG(got)
+	if err != nil || string(again) != out {
+		t.Errorf("sanitizing again = %s, %v, want unchanged", again, err)
+	}
+}
+
+func TestSanitizeSVGRefused(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		svg  string
+	}{
+		{"not xml", `GIF89a`},
+		{"empty", ``},
+		{"html", `<html><body><script>alert(1)</script></body></html>`},
+		{"xhtml root", `<svg xmlns="http://www.w3.org/1999/xhtml"></svg>`},
+		{"unclosed", `<svg xmlns="http://www.w3.org/2000/svg"><text>build`},
+		{"undefined entity", `<!DOCTYPE svg [<!ENTITY x "<script>alert(1)</script>">]><svg>&x;</svg>`},
+		{"second root", `<svg></svg><svg onload="alert(1)"></svg>`},
+	}
+	for _, tt := range tests {
+		if got, err := sanitizeSVG([]byte(tt.svg)); err == nil {
+			t.Errorf("%s: sanitizeSVG() = %s, want an error", tt.name, got)
+		}
+	}
+}
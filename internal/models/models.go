@@ -1,19 +1,87 @@
 package models
 
-// Badge represents a single badge found in a README.
+import "time"
+
+// SchemaVersion is the version of the crawl data schema written by this build.
+// Version 1 is the original unversioned format.
+const SchemaVersion = 2
+
+// Badge represents a single badge found in a README or other indexed file.
+// Source is the file's path relative to the repository root and Line the
+// 1-based line the badge starts on.
 type Badge struct {
 	AltText    string `json:"alt_text"`
 	ImageURL   string `json:"image_url"`
 	TargetURL  string `json:"target_url"`
 	HostImage  string `json:"host_image"`
 	HostTarget string `json:"host_target"`
+	Source     string `json:"source,omitempty"`
+	Line       int    `json:"line,omitempty"`
+	// ImageStatus and TargetStatus are the results of the last liveness
+	// check of the badge image and link, when one was run.
+	ImageStatus  *LinkStatus `json:"image_status,omitempty"`
+	TargetStatus *LinkStatus `json:"target_status,omitempty"`
+}
+
+// LinkStatus is the outcome of fetching a badge image or link URL. Error is
+// set when no HTTP response was received.
+type LinkStatus struct {
+	StatusCode  int       `json:"status_code,omitempty"`
+	ContentType string    `json:"content_type,omitempty"`
+	LatencyMS   int64     `json:"latency_ms"`
+	Error       string    `json:"error,omitempty"`
+	CheckedAt   time.Time `json:"checked_at"`
+	// Label and Message are the text areas of an SVG badge image, such as
+	// "build" and "passing".
+	Label   string `json:"label,omitempty"`
+	Message string `json:"message,omitempty"`
 }
 
 // RepositoryData represents the crawled data for a single repository.
 type RepositoryData struct {
-	Repository    string  `json:"repository"`
-	RepositoryURL string  `json:"repository_url"`
-	DefaultBranch string  `json:"default_branch"`
+	SchemaVersion int      `json:"schema_version"`
+	Repository    string   `json:"repository"`
+	RepositoryURL string   `json:"repository_url"`
+	DefaultBranch string   `json:"default_branch"`
+	Ref           string   `json:"ref,omitempty"`
+	Private       bool     `json:"private,omitempty"`
+	Language      string   `json:"language,omitempty"`
+	Topics        []string `json:"topics,omitempty"`
+	ReadmeFound   bool     `json:"readme_found"`
+	ReadmePath    string   `json:"readme_path,omitempty"`
+	Badges        []Badge  `json:"badges"`
+}
+
+// OrganizationData represents the crawled organization profile README, read
+// from profile/README.md in the organization's .github repository.
+type OrganizationData struct {
+	SchemaVersion int     `json:"schema_version"`
+	Organization  string  `json:"organization"`
+	ProfileURL    string  `json:"profile_url"`
+	ReadmeURL     string  `json:"readme_url,omitempty"`
 	ReadmeFound   bool    `json:"readme_found"`
 	Badges        []Badge `json:"badges"`
 }
+
+// CrawlConfig records the options a crawl was run with.
+type CrawlConfig struct {
+	IncludePrivate bool                `json:"include_private"`
+	Strategy       string              `json:"strategy,omitempty"`
+	Refs           []string            `json:"refs,omitempty"`
+	RefOverrides   map[string][]string `json:"ref_overrides,omitempty"`
+	ExtraPaths     []string            `json:"extra_paths,omitempty"`
+	BadgeDomains   []string            `json:"badge_domains,omitempty"`
+}
+
+// Bundle is a complete crawl in a single artifact: metadata, crawl time, the
+// crawl configuration, the organization profile and every repository.
+type Bundle struct {
+	Format        string            `json:"format"`
+	FormatVersion int               `json:"format_version"`
+	SchemaVersion int               `json:"schema_version"`
+	Organization  string            `json:"organization"`
+	CrawledAt     time.Time         `json:"crawled_at"`
+	Config        CrawlConfig       `json:"config"`
+	Profile       *OrganizationData `json:"profile,omitempty"`
+	Repositories  []RepositoryData  `json:"repositories"`
+}
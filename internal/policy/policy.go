@@ -0,0 +1,300 @@
+// Package policy evaluates which badges repositories are required to carry
+// and which they must not use, as configured in a YAML policy file.
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/catalog"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+)
+
+// Repository visibilities a rule can be scoped to.
+const (
+	VisibilityPublic  = "public"
+	VisibilityPrivate = "private"
+)
+
+// Policy is a set of badge rules.
+type Policy struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule requires or forbids badges in the repositories within its scope.
+type Rule struct {
+	Name        string     `yaml:"name"`
+	Description string     `yaml:"description"`
+	Scope       Scope      `yaml:"scope"`
+	Require     []Selector `yaml:"require"`
+	Forbid      []Selector `yaml:"forbid"`
+}
+
+// Scope limits a rule to matching repositories. Every non-empty field must
+// match; within a list, any entry may match. An empty scope matches every
+// repository.
+type Scope struct {
+	Visibility string   `yaml:"visibility"`
+	Languages  []string `yaml:"languages"`
+	Topics     []string `yaml:"topics"`
+	// Repos are repository name globs in path.Match syntax.
+	Repos []string `yaml:"repos"`
+}
+
+// Selector matches badges by their badges.json entry or by pattern. Every
+// non-empty field must match; ID, name and category compare without case,
+// and Pattern uses the badges.json pattern syntax.
+type Selector struct {
+	ID       string `yaml:"id"`
+	Name     string `yaml:"name"`
+	Category string `yaml:"category"`
+	Pattern  string `yaml:"pattern"`
+}
+
+// String describes the selector for reports.
+func (s Selector) String() string {
+	var parts []string
+	if s.ID != "" {
+		parts = append(parts, fmt.Sprintf("id %q", s.ID))
+	}
+	if s.Name != "" {
+		parts = append(parts, fmt.Sprintf("name %q", s.Name))
+	}
+	if s.Category != "" {
+		parts = append(parts, fmt.Sprintf("category %q", s.Category))
+	}
+	if s.Pattern != "" {
+		parts = append(parts, fmt.Sprintf("pattern %s", s.Pattern))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Badge is a repository badge identified against the catalog. Index is its
+// position in the repository's crawled badge list.
+type Badge struct {
+	Index    int
+	ID       string
+	Name     string
+	Category string
+	Pattern  string
+}
+
+// Identify looks up every badge of a repository in the catalog.
+func Identify(repo models.RepositoryData, orgName string, config *catalog.Config) []Badge {
+	badges := make([]Badge, 0, len(repo.Badges))
+	for i, b := range repo.Badges {
+		pattern := catalog.Canonicalize(b.ImageURL, orgName, repo.Repository)
+		name, category, _, id := config.Lookup(pattern)
+		badges = append(badges, Badge{
+			Index:    i,
+			ID:       id,
+			Name:     name,
+			Category: category,
+			Pattern:  pattern,
+		})
+	}
+	return badges
+}
+
+// MatchesEntry reports whether badges identified as the catalog entry would
+// match the selector.
+func (s Selector) MatchesEntry(entry catalog.Entry) bool {
+	return s.matches(Badge{ID: entry.ID, Name: entry.Name, Category: entry.Category, Pattern: entry.Pattern})
+}
+
+func (s Selector) matches(b Badge) bool {
+	if s.ID != "" && !strings.EqualFold(s.ID, b.ID) {
+		return false
+	}
+	if s.Name != "" && !strings.EqualFold(s.Name, b.Name) {
+		return false
+	}
+	if s.Category != "" && !strings.EqualFold(s.Category, b.Category) {
+		return false
+	}
+	if s.Pattern != "" && !catalog.Matches(s.Pattern, b.Pattern) {
+		return false
+	}
+	return true
+}
+
+// Load reads and validates a policy file.
+func Load(policyPath string) (*Policy, error) {
+	data, err := os.ReadFile(policyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file: %w", err)
+	}
+	if err := p.validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", policyPath, err)
+	}
+	return &p, nil
+}
+
+func (p *Policy) validate() error {
+	names := make(map[string]struct{})
+	for i, rule := range p.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("rule %d has no name", i+1)
+		}
+		if _, ok := names[rule.Name]; ok {
+			return fmt.Errorf("duplicate rule name %q", rule.Name)
+		}
+		names[rule.Name] = struct{}{}
+
+		if len(rule.Require) == 0 && len(rule.Forbid) == 0 {
+			return fmt.Errorf("rule %q neither requires nor forbids any badge", rule.Name)
+		}
+		switch rule.Scope.Visibility {
+		case "", VisibilityPublic, VisibilityPrivate:
+		default:
+			return fmt.Errorf("rule %q: unknown visibility %q (expected %s or %s)", rule.Name, rule.Scope.Visibility, VisibilityPublic, VisibilityPrivate)
+		}
+		for _, pattern := range rule.Scope.Repos {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("rule %q: invalid repository pattern %q: %w", rule.Name, pattern, err)
+			}
+		}
+		for _, s := range append(append([]Selector{}, rule.Require...), rule.Forbid...) {
+			if s == (Selector{}) {
+				return fmt.Errorf("rule %q has an empty badge selector", rule.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Applies reports whether the repository is within the rule's scope.
+func (s Scope) Applies(repo models.RepositoryData) bool {
+	switch s.Visibility {
+	case VisibilityPublic:
+		if repo.Private {
+			return false
+		}
+	case VisibilityPrivate:
+		if !repo.Private {
+			return false
+		}
+	}
+	if len(s.Languages) > 0 && !containsFold(s.Languages, repo.Language) {
+		return false
+	}
+	if len(s.Topics) > 0 && !anyContainsFold(s.Topics, repo.Topics) {
+		return false
+	}
+	if len(s.Repos) > 0 {
+		name := strings.ToLower(repo.Repository)
+		matched := false
+		for _, pattern := range s.Repos {
+			if ok, _ := path.Match(strings.ToLower(pattern), name); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// Result is the outcome of every rule in scope for a repository.
+type Result struct {
+	Rules []RuleResult
+}
+
+// RuleResult is the outcome of one rule for a repository.
+type RuleResult struct {
+	Rule      *Rule
+	Missing   []Selector
+	Forbidden []Violation
+}
+
+// Violation is a badge matched by a forbid selector.
+type Violation struct {
+	Selector Selector
+	Badge    Badge
+}
+
+// Passed reports whether the repository satisfies the rule.
+func (r RuleResult) Passed() bool {
+	return len(r.Missing) == 0 && len(r.Forbidden) == 0
+}
+
+// Applicable reports whether any rule applies to the repository.
+func (r Result) Applicable() bool {
+	return len(r.Rules) > 0
+}
+
+// Compliant reports whether the repository passes every applicable rule.
+func (r Result) Compliant() bool {
+	for _, rule := range r.Rules {
+		if !rule.Passed() {
+			return false
+		}
+	}
+	return true
+}
+
+// Evaluate checks a repository's identified badges against every rule in
+// scope.
+func (p *Policy) Evaluate(repo models.RepositoryData, badges []Badge) Result {
+	var result Result
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if !rule.Scope.Applies(repo) {
+			continue
+		}
+
+		ruleResult := RuleResult{Rule: rule}
+		for _, s := range rule.Require {
+			if !anyMatch(s, badges) {
+				ruleResult.Missing = append(ruleResult.Missing, s)
+			}
+		}
+		for _, s := range rule.Forbid {
+			for _, b := range badges {
+				if s.matches(b) {
+					ruleResult.Forbidden = append(ruleResult.Forbidden, Violation{Selector: s, Badge: b})
+				}
+			}
+		}
+		result.Rules = append(result.Rules, ruleResult)
+	}
+	return result
+}
+
+func anyMatch(s Selector, badges []Badge) bool {
+	for _, b := range badges {
+		if s.matches(b) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func anyContainsFold(list, values []string) bool {
+	for _, value := range values {
+		if containsFold(list, value) {
+			return true
+		}
+	}
+	return false
+}
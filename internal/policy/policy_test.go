@@ -0,0 +1,152 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+)
+
+const testPolicy = `
+rules:
+  - name: go-standards
+    description: Public Go repositories show license, report card and build badges.
+    scope:
+      visibility: public
+      languages: [Go]
+    require:
+      - category: License
+      - name: Go Report Card
+      - category: Build
+  - name: no-travis
+    forbid:
+      - pattern: https://travis-ci.org/{ORG}/{REPO}/.*
+  - name: services-documented
+    scope:
+      repos: ["*-service"]
+      topics: [api]
+    require:
+      - id: docs
+`
+
+func writePolicy(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestEvaluate(t *testing.T) {
+	t.Parallel()
+
+	p, err := Load(writePolicy(t, testPolicy))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	license := Badge{Index: 0, ID: "license", Name: "License", Category: "License", Pattern: "https://img.shields.io/github/license/{ORG}/{REPO}/.*"}
+	reportCard := Badge{Index: 1, ID: "goreportcard", Name: "Go Report Card", Category: "Quality", Pattern: "https://goreportcard.com/badge/github.com/{ORG}/{REPO}/.*"}
+	travis := Badge{Index: 2, ID: "travis", Name: "Travis CI", Category: "Build", Pattern: "https://travis-ci.org/{ORG}/{REPO}/.*"}
+	actions := Badge{Index: 2, ID: "actions", Name: "GitHub Actions", Category: "Build", Pattern: "https://github.com/{ORG}/{REPO}/.*"}
+
+	tests := []struct {
+		name        string
+		repo        models.RepositoryData
+		badges      []Badge
+		wantRules   []string
+		wantFailing map[string]string
+	}{
+		{
+			name:      "compliant go repository",
+			repo:      models.RepositoryData{Repository: "tool", Language: "go"},
+			badges:    []Badge{license, reportCard, actions},
+			wantRules: []string{"go-standards", "no-travis"},
+		},
+		{
+			name:        "missing badges and forbidden travis",
+			repo:        models.RepositoryData{Repository: "legacy", Language: "Go"},
+			badges:      []Badge{license, travis},
+			wantRules:   []string{"go-standards", "no-travis"},
+			wantFailing: map[string]string{"go-standards": `missing name "Go Report Card"`, "no-travis": "forbidden Travis CI"},
+		},
+		{
+			name:      "private repositories are out of public scope",
+			repo:      models.RepositoryData{Repository: "internal", Language: "Go", Private: true},
+			badges:    nil,
+			wantRules: []string{"no-travis"},
+		},
+		{
+			name:        "name and topic scope",
+			repo:        models.RepositoryData{Repository: "Billing-Service", Language: "Java", Topics: []string{"API", "billing"}},
+			badges:      []Badge{license},
+			wantRules:   []string{"no-travis", "services-documented"},
+			wantFailing: map[string]string{"services-documented": `missing id "docs"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result := p.Evaluate(tt.repo, tt.badges)
+
+			var rules []string
+			for _, r := range result.Rules {
+				rules = append(rules, r.Rule.Name)
+
+				var got []string
+				for _, s := range r.Missing {
+					got = append(got, "missing "+s.String())
+				}
+				for _, v := range r.Forbidden {
+					got = append(got, "forbidden "+v.Badge.Name)
+				}
+				want, failing := tt.wantFailing[r.Rule.Name]
+				if r.Passed() == failing {
+					t.Errorf("rule %s passed = %v, want %v (%v)", r.Rule.Name, r.Passed(), !failing, got)
+				}
+				if failing && !strings.Contains(strings.Join(got, "; "), want) {
+					t.Errorf("rule %s failures = %v, want %q", r.Rule.Name, got, want)
+				}
+			}
+			if strings.Join(rules, ",") != strings.Join(tt.wantRules, ",") {
+				t.Errorf("applicable rules = %v, want %v", rules, tt.wantRules)
+			}
+			if result.Compliant() != (len(tt.wantFailing) == 0) {
+				t.Errorf("Compliant() = %v, want %v", result.Compliant(), len(tt.wantFailing) == 0)
+			}
+		})
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "unnamed rule", content: "rules:\n  - require: [{name: License}]\n", wantErr: "has no name"},
+		{name: "duplicate name", content: "rules:\n  - name: a\n    require: [{name: x}]\n  - name: a\n    require: [{name: y}]\n", wantErr: "duplicate rule name"},
+		{name: "no badges", content: "rules:\n  - name: a\n", wantErr: "neither requires nor forbids"},
+		{name: "empty selector", content: "rules:\n  - name: a\n    forbid: [{}]\n", wantErr: "empty badge selector"},
+		{name: "bad visibility", content: "rules:\n  - name: a\n    scope: {visibility: internal}\n    require: [{name: x}]\n", wantErr: "unknown visibility"},
+		{name: "bad glob", content: "rules:\n  - name: a\n    scope: {repos: [\"[\"]}\n    require: [{name: x}]\n", wantErr: "invalid repository pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Load(writePolicy(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
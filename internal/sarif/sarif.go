@@ -0,0 +1,422 @@
+// Package sarif reports badge policy violations and broken badges as a SARIF
+// 2.1.0 log, so they can be ingested alongside other code scanning results.
+package sarif
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/catalog"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/dataset"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/liveness"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/policy"
+)
+
+// SARIF identifiers.
+const (
+	Version   = "2.1.0"
+	SchemaURI = "https://json.schemastore.org/sarif-2.1.0.json"
+	toolName  = "badgeindexer"
+	toolURI   = "https://github.com/UnitVectorY-Labs/badgeindexer"
+)
+
+// brokenRuleID is the rule of badges whose image or link the last liveness
+// check found broken; it applies to every repository, whatever the policy.
+const brokenRuleID = "broken-badge"
+
+// srcRoot is the base of the repository-relative file paths of results; each
+// run maps it to its repository through versionControlProvenance.
+const srcRoot = "SRCROOT"
+
+// fingerprintKey names the partial fingerprint that identifies a violation
+// across runs, independent of line moves.
+const fingerprintKey = "badgeindexer/v1"
+
+// Log is a SARIF log file.
+type Log struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []Run  `json:"runs"`
+}
+
+// Run is the analysis of a single repository.
+type Run struct {
+	Tool                     Tool                    `json:"tool"`
+	AutomationDetails        *AutomationDetails      `json:"automationDetails,omitempty"`
+	VersionControlProvenance []VersionControlDetails `json:"versionControlProvenance,omitempty"`
+	Results                  []Result                `json:"results"`
+}
+
+// AutomationDetails tells the runs of a log apart; code scanning uses the ID
+// as the category of their results.
+type AutomationDetails struct {
+	ID string `json:"id"`
+}
+
+// VersionControlDetails names the repository and ref the files of a run are
+// in, and maps srcRoot to the repository root.
+type VersionControlDetails struct {
+	RepositoryURI string           `json:"repositoryUri"`
+	Branch        string           `json:"branch,omitempty"`
+	MappedTo      ArtifactLocation `json:"mappedTo"`
+}
+
+// Tool describes the analysis tool.
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+// Driver is the tool component that produced the results.
+type Driver struct {
+	Name           string `json:"name"`
+	InformationURI string `json:"informationUri"`
+	Rules          []Rule `json:"rules"`
+}
+
+// Rule is a reportingDescriptor for one policy selector, or for broken badges.
+type Rule struct {
+	ID                   string         `json:"id"`
+	ShortDescription     Message        `json:"shortDescription"`
+	FullDescription      *Message       `json:"fullDescription,omitempty"`
+	Help                 *Message       `json:"help,omitempty"`
+	DefaultConfiguration Configuration  `json:"defaultConfiguration"`
+	Properties           RuleProperties `json:"properties"`
+}
+
+// Configuration holds a rule's default severity.
+type Configuration struct {
+	Level string `json:"level"`
+}
+
+// RuleProperties carries the policy metadata of a rule.
+type RuleProperties struct {
+	Tags     []string `json:"tags"`
+	Policy   string   `json:"policy,omitempty"`
+	Selector string   `json:"selector,omitempty"`
+}
+
+// Message is a SARIF message with optional Markdown.
+type Message struct {
+	Text     string `json:"text"`
+	Markdown string `json:"markdown,omitempty"`
+}
+
+// Result is a single violation. Missing badges of repositories without a
+// README have no location.
+type Result struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             Message           `json:"message"`
+	Locations           []Location        `json:"locations,omitempty"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+// Location anchors a result to a file and line.
+type Location struct {
+	PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+}
+
+// PhysicalLocation is a file region.
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           Region           `json:"region"`
+}
+
+// ArtifactLocation is the file a result is reported in, relative to the
+// base URI ID.
+type ArtifactLocation struct {
+	URI       string `json:"uri,omitempty"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+// Region is the line a result is reported on.
+type Region struct {
+	StartLine int `json:"startLine"`
+}
+
+// Build evaluates the policy, which may be nil, against every repository in
+// the crawl and reports each missing or forbidden badge, and every badge the
+// last liveness check found broken, in one run per repository. Missing
+// badges are anchored to the repository README, on the line of its first
+// badge; forbidden and broken badges to the file and line they were found
+// on.
+func Build(crawl *models.Bundle, p *policy.Policy, config *catalog.Config) *Log {
+	rules, index := buildRules(p, config)
+	log := &Log{
+		Schema:  SchemaURI,
+		Version: Version,
+		Runs:    []Run{},
+	}
+
+	orgName := dataset.OrganizationName(crawl)
+	for _, repo := range crawl.Repositories {
+		run := newRun(slices.Clone(rules))
+		run.AutomationDetails = &AutomationDetails{ID: toolName + "/" + repo.Repository + "/"}
+		if repo.RepositoryURL != "" {
+			ref := repo.Ref
+			if ref == "" {
+				ref = repo.DefaultBranch
+			}
+			run.VersionControlProvenance = []VersionControlDetails{{
+				RepositoryURI: repo.RepositoryURL,
+				Branch:        ref,
+				MappedTo:      ArtifactLocation{URIBaseID: srcRoot},
+			}}
+		}
+		ruleIndex := maps.Clone(index)
+
+		var result policy.Result
+		if p != nil {
+			result = p.Evaluate(repo, policy.Identify(repo, orgName, config))
+		}
+		for _, r := range result.Rules {
+			for _, s := range r.Missing {
+				id := ruleID(r.Rule.Name, "require", r.Rule.Require, s)
+				var locations []Location
+				if repo.ReadmeFound {
+					readme := readmePath(repo)
+					locations = []Location{location(readme, firstBadgeLine(repo, readme))}
+				}
+				run.Results = append(run.Results, Result{
+					RuleID:    id,
+					RuleIndex: ruleIndex[id],
+					Level:     "warning",
+					Message: Message{
+						Text: fmt.Sprintf("%s is missing a badge with %s required by policy rule %s.", repo.Repository, s, r.Rule.Name),
+					},
+					Locations:           locations,
+					PartialFingerprints: fingerprint(repo.Repository, id),
+				})
+			}
+			for _, v := range r.Forbidden {
+				id := ruleID(r.Rule.Name, "forbid", r.Rule.Forbid, v.Selector)
+				badge := repo.Badges[v.Badge.Index]
+				run.Results = append(run.Results, Result{
+					RuleID:    id,
+					RuleIndex: ruleIndex[id],
+					Level:     "error",
+					Message: Message{
+						Text: fmt.Sprintf("%s uses the badge %s, forbidden by policy rule %s.", repo.Repository, badgeLabel(v.Badge), r.Rule.Name),
+					},
+					Locations:           []Location{location(badgeSource(repo, badge), max(badge.Line, 1))},
+					PartialFingerprints: fingerprint(repo.Repository, id, v.Badge.Pattern),
+				})
+			}
+		}
+
+		for _, badge := range repo.Badges {
+			problem := brokenProblem(badge)
+			if problem == "" {
+				continue
+			}
+			if _, ok := ruleIndex[brokenRuleID]; !ok {
+				ruleIndex[brokenRuleID] = len(run.Tool.Driver.Rules)
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, brokenRule())
+			}
+			run.Results = append(run.Results, Result{
+				RuleID:    brokenRuleID,
+				RuleIndex: ruleIndex[brokenRuleID],
+				Level:     "warning",
+				Message: Message{
+					Text: fmt.Sprintf("%s has a broken badge %s: %s.", repo.Repository, badgeName(badge), problem),
+				},
+				Locations:           []Location{location(badgeSource(repo, badge), max(badge.Line, 1))},
+				PartialFingerprints: fingerprint(repo.Repository, brokenRuleID, catalog.Canonicalize(badge.ImageURL, orgName, repo.Repository)),
+			})
+		}
+		log.Runs = append(log.Runs, run)
+	}
+
+	// A log needs a run to name the tool, even without repositories
+	if len(log.Runs) == 0 {
+		log.Runs = append(log.Runs, newRun(rules))
+	}
+	return log
+}
+
+// newRun starts a run of the tool with the rules.
+func newRun(rules []Rule) Run {
+	return Run{
+		Tool: Tool{Driver: Driver{
+			Name:           toolName,
+			InformationURI: toolURI,
+			Rules:          rules,
+		}},
+		Results: []Result{},
+	}
+}
+
+// Write writes the log as indented JSON.
+func Write(path string, log *Log) error {
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode SARIF log: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write SARIF log %s: %w", path, err)
+	}
+	return nil
+}
+
+// buildRules describes one SARIF rule per policy selector. The help text
+// lists the badges.json entries the selector accepts or rejects.
+func buildRules(p *policy.Policy, config *catalog.Config) ([]Rule, map[string]int) {
+	rules := []Rule{}
+	index := make(map[string]int)
+	add := func(rule policy.Rule, kind string, selectors []policy.Selector) {
+		for _, s := range selectors {
+			id := ruleID(rule.Name, kind, selectors, s)
+			if _, ok := index[id]; ok {
+				continue
+			}
+
+			short := fmt.Sprintf("Missing badge with %s", s)
+			level := "warning"
+			help := "Add one of these badges to the README:"
+			if kind == "forbid" {
+				short = fmt.Sprintf("Forbidden badge with %s", s)
+				level = "error"
+				help = "Remove these badges:"
+			}
+
+			var entries []string
+			for _, entry := range config.Badges {
+				if s.MatchesEntry(entry) {
+					entries = append(entries, fmt.Sprintf("- %s (%s): `%s`", entry.Name, entry.Category, entry.Pattern))
+				}
+			}
+			helpMessage := &Message{Text: short + ".", Markdown: short + "."}
+			if len(entries) > 0 {
+				helpMessage.Text = help + "\n" + strings.ReplaceAll(strings.Join(entries, "\n"), "`", "")
+				helpMessage.Markdown = help + "\n\n" + strings.Join(entries, "\n")
+			}
+
+			r := Rule{
+				ID:                   id,
+				ShortDescription:     Message{Text: short},
+				Help:                 helpMessage,
+				DefaultConfiguration: Configuration{Level: level},
+				Properties: RuleProperties{
+					Tags:     []string{"badges", "policy"},
+					Policy:   rule.Name,
+					Selector: s.String(),
+				},
+			}
+			if rule.Description != "" {
+				r.FullDescription = &Message{Text: rule.Description}
+			}
+			index[id] = len(rules)
+			rules = append(rules, r)
+		}
+	}
+	if p != nil {
+		for _, rule := range p.Rules {
+			add(rule, "require", rule.Require)
+			add(rule, "forbid", rule.Forbid)
+		}
+	}
+	return rules, index
+}
+
+// brokenRule describes the rule of broken badges.
+func brokenRule() Rule {
+	return Rule{
+		ID:               brokenRuleID,
+		ShortDescription: Message{Text: "Broken badge"},
+		Help: &Message{
+			Text:     "Fix or remove badges whose image or link no longer works. Run badgeindexer -liveness to check them again.",
+			Markdown: "Fix or remove badges whose image or link no longer works. Run `badgeindexer -liveness` to check them again.",
+		},
+		DefaultConfiguration: Configuration{Level: "warning"},
+		Properties:           RuleProperties{Tags: []string{"badges", "liveness"}},
+	}
+}
+
+// brokenProblem describes what the last liveness check found broken about a
+// badge, or is empty when nothing was.
+func brokenProblem(b models.Badge) string {
+	var problems []string
+	if problem := liveness.ImageProblem(b.ImageStatus); problem != "" {
+		problems = append(problems, "image "+problem)
+	}
+	if problem := liveness.TargetProblem(b.TargetStatus); problem != "" {
+		problems = append(problems, "link "+problem)
+	}
+	return strings.Join(problems, "; ")
+}
+
+// badgeName names a badge in messages by its alt text, or its image URL.
+func badgeName(b models.Badge) string {
+	if b.AltText != "" {
+		return b.AltText
+	}
+	return b.ImageURL
+}
+
+// ruleID identifies a selector by policy rule, kind and 1-based position.
+func ruleID(ruleName, kind string, selectors []policy.Selector, s policy.Selector) string {
+	for i, candidate := range selectors {
+		if candidate == s {
+			return fmt.Sprintf("%s/%s/%d", ruleName, kind, i+1)
+		}
+	}
+	return fmt.Sprintf("%s/%s", ruleName, kind)
+}
+
+// readmePath is the README the crawl read, defaulting to README.md for
+// crawls that did not record it.
+func readmePath(repo models.RepositoryData) string {
+	if repo.ReadmePath != "" {
+		return repo.ReadmePath
+	}
+	return "README.md"
+}
+
+// badgeSource is the file a badge was found in; crawls that did not record
+// it only read the README.
+func badgeSource(repo models.RepositoryData, b models.Badge) string {
+	if b.Source != "" {
+		return b.Source
+	}
+	return readmePath(repo)
+}
+
+// firstBadgeLine is the line of the first badge in the file, where a missing
+// badge would be expected, or 1 when the file has none.
+func firstBadgeLine(repo models.RepositoryData, source string) int {
+	for _, b := range repo.Badges {
+		if (b.Source == source || b.Source == "") && b.Line > 0 {
+			return b.Line
+		}
+	}
+	return 1
+}
+
+// location anchors a result to a line of a file, relative to the root of
+// the run's repository.
+func location(file string, line int) Location {
+	return Location{PhysicalLocation: PhysicalLocation{
+		ArtifactLocation: ArtifactLocation{URI: file, URIBaseID: srcRoot},
+		Region:           Region{StartLine: line},
+	}}
+}
+
+func fingerprint(parts ...string) map[string]string {
+	h := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return map[string]string{fingerprintKey: hex.EncodeToString(h[:])[:32]}
+}
+
+func badgeLabel(b policy.Badge) string {
+	if b.Name == catalog.Unknown {
+		return b.Pattern
+	}
+	return b.Name
+}
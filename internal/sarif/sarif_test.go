@@ -0,0 +1,186 @@
+package sarif
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/catalog"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/policy"
+)
+
+func TestBuild(t *testing.T) {
+	t.Parallel()
+
+	config := &catalog.Config{Badges: []catalog.Entry{
+		{ID: "license", Pattern: "https://img.shields.io/github/license/{ORG}/{REPO}/.*", Name: "License", Category: "License"},
+		{ID: "travis", Pattern: "https://travis-ci.org/{ORG}/{REPO}/.*", Name: "Travis CI", Category: "Build"},
+	}}
+	p := &policy.Policy{Rules: []policy.Rule{
+		{
+			Name:        "standards",
+			Description: "Repositories show a license badge.",
+			Require:     []policy.Selector{{Category: "License"}},
+			Forbid:      []policy.Selector{{ID: "travis"}},
+		},
+	}}
+	crawl := &models.Bundle{
+		Organization: "example",
+		Repositories: []models.RepositoryData{
+			{
+				Repository:    "legacy",
+				RepositoryURL: "https://github.com/example/legacy",
+				DefaultBranch: "main",
+				Ref:           "release",
+				ReadmeFound:   true,
+				ReadmePath:    "README.md",
+				Badges: []models.Badge{
+					{ImageURL: "https://coveralls.io/repos/example/legacy/badge.svg", Source: "README.md", Line: 3},
+					{ImageURL: "https://travis-ci.org/example/legacy.svg", Source: "docs/index.md", Line: 7},
+				},
+			},
+			{
+				Repository:    "tool",
+				RepositoryURL: "https://github.com/example/tool",
+				DefaultBranch: "main",
+				ReadmeFound:   true,
+				Badges:        []models.Badge{{ImageURL: "https://img.shields.io/github/license/example/tool", Line: 1}},
+			},
+			{
+				Repository:    "empty",
+				RepositoryURL: "https://github.com/example/empty",
+				DefaultBranch: "main",
+			},
+		},
+	}
+
+	log := Build(crawl, p, config)
+
+	if log.Version != Version || len(log.Runs) != 3 {
+		t.Fatalf("Build() = version %s with %d runs, want one per repository", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+	if got := run.VersionControlProvenance; len(got) != 1 || got[0].RepositoryURI != "https://github.com/example/legacy" ||
+		got[0].Branch != "release" || got[0].MappedTo.URIBaseID != srcRoot {
+		t.Errorf("versionControlProvenance = %+v, want the legacy repository at release", got)
+	}
+	if run.AutomationDetails == nil || run.AutomationDetails.ID == log.Runs[1].AutomationDetails.ID {
+		t.Errorf("automationDetails = %+v, want a category per repository", run.AutomationDetails)
+	}
+	if len(run.Tool.Driver.Rules) != 2 {
+		t.Fatalf("rules = %+v, want one per selector", run.Tool.Driver.Rules)
+	}
+	if help := run.Tool.Driver.Rules[0].Help; help == nil || !strings.Contains(help.Markdown, "License (License)") {
+		t.Errorf("require rule help = %+v, want the matching badges.json entry", help)
+	}
+
+	if len(run.Results) != 2 {
+		t.Fatalf("results = %+v, want a missing and a forbidden badge", run.Results)
+	}
+	tests := []struct {
+		ruleID string
+		level  string
+		uri    string
+		line   int
+	}{
+		{"standards/require/1", "warning", "README.md", 3},
+		{"standards/forbid/1", "error", "docs/index.md", 7},
+	}
+	for i, tt := range tests {
+		got := run.Results[i]
+		loc := got.Locations[0].PhysicalLocation
+		if got.RuleID != tt.ruleID || got.Level != tt.level || loc.ArtifactLocation.URI != tt.uri ||
+			loc.ArtifactLocation.URIBaseID != srcRoot || loc.Region.StartLine != tt.line {
+			t.Errorf("result %d = %s %s %s:%d, want %s %s %s:%d", i, got.RuleID, got.Level, loc.ArtifactLocation.URI, loc.Region.StartLine, tt.ruleID, tt.level, tt.uri, tt.line)
+		}
+		if run.Tool.Driver.Rules[got.RuleIndex].ID != got.RuleID {
+			t.Errorf("result %d ruleIndex %d does not point at %s", i, got.RuleIndex, got.RuleID)
+		}
+		if got.PartialFingerprints[fingerprintKey] == "" {
+			t.Errorf("result %d has no fingerprint", i)
+		}
+	}
+
+	if got := log.Runs[1].Results; len(got) != 0 {
+		t.Errorf("tool results = %+v, want none", got)
+	}
+	// A missing badge has nowhere to go without a README
+	if got := log.Runs[2].Results; len(got) != 1 || got[0].RuleID != "standards/require/1" || got[0].Locations != nil {
+		t.Errorf("empty results = %+v, want a missing badge without a location", got)
+	}
+}
+
+func TestBuildBrokenBadges(t *testing.T) {
+	t.Parallel()
+
+	crawl := &models.Bundle{
+		Organization: "example",
+		Repositories: []models.RepositoryData{{
+			Repository:    "tool",
+			RepositoryURL: "https://github.com/example/tool",
+			DefaultBranch: "main",
+			Badges: []models.Badge{
+				{AltText: "Build", ImageURL: "https://ci.example.com/example/tool.svg", Source: "docs/index.md", Line: 4,
+					ImageStatus: &models.LinkStatus{StatusCode: 404}, TargetStatus: &models.LinkStatus{Error: "timed out"}},
+				{AltText: "License", ImageURL: "https://img.shields.io/github/license/example/tool", Line: 2,
+					ImageStatus: &models.LinkStatus{StatusCode: 200, ContentType: "text/html"}},
+				{AltText: "Working", ImageURL: "https://ok.example.com/badge.svg", Line: 3,
+					ImageStatus: &models.LinkStatus{StatusCode: 200, ContentType: "image/svg+xml"}, TargetStatus: &models.LinkStatus{StatusCode: 301}},
+				{AltText: "Unchecked", ImageURL: "https://unchecked.example.com/badge.svg", Line: 5},
+			},
+		}},
+	}
+
+	// Broken badges are reported without a policy
+	run := Build(crawl, nil, &catalog.Config{}).Runs[0]
+	if len(run.Tool.Driver.Rules) != 1 || run.Tool.Driver.Rules[0].ID != brokenRuleID {
+		t.Fatalf("rules = %+v, want only the broken badge rule", run.Tool.Driver.Rules)
+	}
+	tests := []struct {
+		message string
+		uri     string
+		line    int
+	}{
+		{"tool has a broken badge Build: image HTTP 404 Not Found; link timed out.", "docs/index.md", 4},
+		{"tool has a broken badge License: image not an image (text/html).", "README.md", 2},
+	}
+	if len(run.Results) != len(tests) {
+		t.Fatalf("results = %+v, want the two broken badges", run.Results)
+	}
+	for i, tt := range tests {
+		got := run.Results[i]
+		loc := got.Locations[0].PhysicalLocation
+		if got.RuleID != brokenRuleID || got.RuleIndex != 0 || got.Message.Text != tt.message || loc.ArtifactLocation.URI != tt.uri || loc.Region.StartLine != tt.line {
+			t.Errorf("result %d = %s %q %s:%d, want %s %q %s:%d", i, got.RuleID, got.Message.Text, loc.ArtifactLocation.URI, loc.Region.StartLine, brokenRuleID, tt.message, tt.uri, tt.line)
+		}
+	}
+	if run.Results[0].PartialFingerprints[fingerprintKey] == run.Results[1].PartialFingerprints[fingerprintKey] {
+		t.Error("broken badges share a fingerprint")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "badges.sarif")
+	log := Build(&models.Bundle{}, &policy.Policy{}, &catalog.Config{})
+	if err := Write(path, log); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Write() produced invalid JSON: %v", err)
+	}
+	// An empty run still lists its results, as SARIF consumers expect.
+	if !strings.Contains(string(data), `"results": []`) || decoded["$schema"] != SchemaURI {
+		t.Errorf("Write() = %s", data)
+	}
+}
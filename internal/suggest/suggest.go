@@ -0,0 +1,336 @@
+// Package suggest drafts badges.json entries for the badges a crawl found
+// that the catalog does not know yet.
+package suggest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/catalog"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/dataset"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+)
+
+// Suggestion is a proposed catalog entry. Repos, Examples and Reason help
+// review the draft; catalog loading ignores them, so a reviewed draft can be
+// merged into badges.json as is.
+type Suggestion struct {
+	catalog.Entry
+	Repos    int      `json:"repos"`
+	Examples []string `json:"examples"`
+	Reason   string   `json:"inferred_from"`
+}
+
+// Draft is the suggestions file, in the badges.json layout.
+type Draft struct {
+	Badges []Suggestion `json:"badges"`
+}
+
+// cluster collects every occurrence of one unknown canonical pattern.
+type cluster struct {
+	pattern  string
+	repos    map[string]struct{}
+	altTexts map[string]int
+	examples []string
+}
+
+// maxExamples bounds the example image URLs kept per suggestion.
+const maxExamples = 3
+
+// Build clusters the crawl's unknown badges by canonical pattern and infers a
+// name and category for each, most widely used first.
+func Build(crawl *models.Bundle, config *catalog.Config) *Draft {
+	orgName := dataset.OrganizationName(crawl)
+	clusters := make(map[string]*cluster)
+	for _, repo := range crawl.Repositories {
+		for _, b := range repo.Badges {
+			pattern := catalog.Canonicalize(b.ImageURL, orgName, repo.Repository)
+			if name, _, _, _ := config.Lookup(pattern); name != catalog.Unknown {
+				continue
+			}
+			c, ok := clusters[pattern]
+			if !ok {
+				c = &cluster{pattern: pattern, repos: make(map[string]struct{}), altTexts: make(map[string]int)}
+				clusters[pattern] = c
+			}
+			c.repos[repo.Repository] = struct{}{}
+			if alt := strings.TrimSpace(b.AltText); alt != "" {
+				c.altTexts[alt]++
+			}
+			if len(c.examples) < maxExamples && !contains(c.examples, b.ImageURL) {
+				c.examples = append(c.examples, b.ImageURL)
+			}
+		}
+	}
+
+	draft := &Draft{Badges: []Suggestion{}}
+	ids := make(map[string]int)
+	for _, entry := range config.Badges {
+		ids[entry.ID]++
+	}
+	for _, c := range sortedClusters(clusters) {
+		name, category, reason := infer(c.pattern, c.commonAltText())
+		draft.Badges = append(draft.Badges, Suggestion{
+			Entry: catalog.Entry{
+				ID:       uniqueID(slugify(name), ids),
+				Pattern:  c.pattern,
+				Name:     name,
+				Category: category,
+			},
+			Repos:    len(c.repos),
+			Examples: c.examples,
+			Reason:   reason,
+		})
+	}
+	return draft
+}
+
+// Write writes the draft as indented JSON.
+func Write(path string, draft *Draft) error {
+	data, err := json.MarshalIndent(draft, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode suggestions: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write suggestions %s: %w", path, err)
+	}
+	return nil
+}
+
+func sortedClusters(clusters map[string]*cluster) []*cluster {
+	sorted := make([]*cluster, 0, len(clusters))
+	for _, c := range clusters {
+		sorted = append(sorted, c)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i].repos) != len(sorted[j].repos) {
+			return len(sorted[i].repos) > len(sorted[j].repos)
+		}
+		return sorted[i].pattern < sorted[j].pattern
+	})
+	return sorted
+}
+
+// commonAltText is the most frequent alt text, ties broken alphabetically.
+func (c *cluster) commonAltText() string {
+	best, bestCount := "", 0
+	for alt, count := range c.altTexts {
+		if count > bestCount || (count == bestCount && alt < best) {
+			best, bestCount = alt, count
+		}
+	}
+	return best
+}
+
+// provider is a badge service recognized by host.
+type provider struct {
+	host     string
+	name     string
+	category string
+}
+
+// providers are matched by host suffix, so subdomains are included.
+var providers = []provider{
+	{"goreportcard.com", "Go Report Card", "Quality"},
+	{"codecov.io", "Codecov", "Coverage"},
+	{"coveralls.io", "Coveralls", "Coverage"},
+	{"travis-ci.org", "Travis CI", "Build"},
+	{"travis-ci.com", "Travis CI", "Build"},
+	{"circleci.com", "CircleCI", "Build"},
+	{"ci.appveyor.com", "AppVeyor", "Build"},
+	{"dev.azure.com", "Azure Pipelines", "Build"},
+	{"pkg.go.dev", "Go Reference", "Documentation"},
+	{"godoc.org", "GoDoc", "Documentation"},
+	{"readthedocs.org", "Read the Docs", "Documentation"},
+	{"javadoc.io", "Javadoc", "Documentation"},
+	{"sonarcloud.io", "SonarCloud", "Quality"},
+	{"codeclimate.com", "Code Climate", "Quality"},
+	{"app.codacy.com", "Codacy", "Quality"},
+	{"api.securityscorecards.dev", "OpenSSF Scorecard", "Security"},
+	{"api.scorecard.dev", "OpenSSF Scorecard", "Security"},
+	{"bestpractices.coreinfrastructure.org", "OpenSSF Best Practices", "Security"},
+	{"www.bestpractices.dev", "OpenSSF Best Practices", "Security"},
+	{"snyk.io", "Snyk", "Security"},
+	{"badge.fury.io", "Package Version", "Package"},
+}
+
+// shieldsFamilies maps shields.io path prefixes to names and categories.
+var shieldsFamilies = []struct {
+	prefix   string
+	name     string
+	category string
+}{
+	{"github/license", "License", "License"},
+	{"github/actions/workflow/status", "GitHub Actions", "Build"},
+	{"github/workflow/status", "GitHub Actions", "Build"},
+	{"github/v/release", "Latest Release", "Release"},
+	{"github/release", "Latest Release", "Release"},
+	{"github/v/tag", "Latest Tag", "Release"},
+	{"github/go-mod/go-version", "Go Version", "Package"},
+	{"github/stars", "GitHub Stars", "Social"},
+	{"github/last-commit", "Last Commit", "Activity"},
+	{"github/issues", "Open Issues", "Activity"},
+	{"codecov/c", "Codecov", "Coverage"},
+	{"coverallsCoverage", "Coveralls", "Coverage"},
+	{"maven-central/v", "Maven Central", "Package"},
+	{"npm/v", "npm Version", "Package"},
+	{"npm/dm", "npm Downloads", "Package"},
+	{"pypi/v", "PyPI Version", "Package"},
+	{"pypi/pyversions", "Python Versions", "Package"},
+	{"crates/v", "Crates.io Version", "Package"},
+	{"docker/pulls", "Docker Pulls", "Package"},
+	{"docker/v", "Docker Version", "Package"},
+	{"ossf-scorecard", "OpenSSF Scorecard", "Security"},
+}
+
+// categoryKeywords infers a category from words in a name, alt text or URL,
+// in priority order.
+var categoryKeywords = []struct {
+	category string
+	words    []string
+}{
+	{"License", []string{"license", "licence"}},
+	{"Coverage", []string{"coverage", "codecov", "coveralls"}},
+	{"Build", []string{"build", "ci", "test", "tests", "workflow", "pipeline", "actions"}},
+	{"Security", []string{"security", "scorecard", "snyk", "vulnerabilities", "cii"}},
+	{"Documentation", []string{"doc", "docs", "documentation", "reference", "javadoc", "godoc"}},
+	{"Package", []string{"version", "npm", "pypi", "maven", "crates", "docker", "nuget", "package"}},
+	{"Release", []string{"release", "tag"}},
+	{"Quality", []string{"quality", "report", "lint", "sonar", "maintainability"}},
+	{"Project Status", []string{"status", "wip", "progress", "maintained", "lifecycle", "stability", "experimental", "deprecated"}},
+}
+
+// fallbackCategory is used when nothing suggests a category.
+const fallbackCategory = "Other"
+
+var wordPattern = regexp.MustCompile(`[a-z0-9]+`)
+
+// githubWorkflow matches the path of a GitHub Actions workflow badge.
+var githubWorkflow = regexp.MustCompile(`^[^/]+/[^/]+/(?:actions/workflows/|workflows/[^/]+/badge\.svg$)`)
+
+// infer proposes a name and category for a canonical pattern from its URL
+// structure and its alt text, reporting what the guess was based on.
+func infer(pattern, altText string) (name, category, reason string) {
+	u, err := url.Parse(pattern)
+	if err != nil || u.Host == "" {
+		return fallbackName(pattern, altText), categoryFor(altText + " " + pattern), fallbackReason(altText)
+	}
+	host := strings.ToLower(u.Host)
+	urlPath := strings.Trim(u.Path, "/")
+
+	if host == "img.shields.io" || host == "shields.io" {
+		for _, family := range shieldsFamilies {
+			if strings.HasPrefix(urlPath, family.prefix+"/") || urlPath == family.prefix {
+				return family.name, family.category, "shields.io path " + family.prefix
+			}
+		}
+		if label, ok := strings.CutPrefix(urlPath, "badge/"); ok {
+			label = staticBadgeLabel(label)
+			if label != "" {
+				return label, categoryFor(label + " " + altText), "shields.io static badge label"
+			}
+		}
+	}
+
+	// Workflow badges, also in the legacy form without /actions
+	if host == "github.com" && githubWorkflow.MatchString(urlPath) {
+		return "GitHub Actions", "Build", "GitHub Actions workflow URL"
+	}
+
+	for _, p := range providers {
+		if host == p.host || strings.HasSuffix(host, "."+p.host) {
+			return p.name, p.category, "provider " + p.host
+		}
+	}
+
+	return fallbackName(pattern, altText), categoryFor(altText + " " + urlPath), fallbackReason(altText)
+}
+
+// staticBadgeLabel reads the label of a shields.io static badge path
+// (label-message-color), where "--" escapes a dash and "_" is a space.
+func staticBadgeLabel(spec string) string {
+	spec = strings.TrimSuffix(path.Base(spec), path.Ext(spec))
+	spec = strings.ReplaceAll(spec, "--", "\x00")
+	label := strings.SplitN(spec, "-", 2)[0]
+	label = strings.ReplaceAll(label, "\x00", "-")
+	label = strings.ReplaceAll(label, "__", "\x00")
+	label = strings.ReplaceAll(label, "_", " ")
+	label = strings.ReplaceAll(label, "\x00", "_")
+	if unescaped, err := url.PathUnescape(label); err == nil {
+		label = unescaped
+	}
+	return strings.TrimSpace(label)
+}
+
+// fallbackName names a badge by its alt text or, without one, by the host.
+func fallbackName(pattern, altText string) string {
+	if altText != "" {
+		return altText
+	}
+	if u, err := url.Parse(pattern); err == nil && u.Host != "" {
+		return strings.TrimPrefix(u.Host, "www.")
+	}
+	return pattern
+}
+
+func fallbackReason(altText string) string {
+	if altText != "" {
+		return "alt text"
+	}
+	return "host"
+}
+
+// categoryFor picks the first category whose keywords appear in text.
+func categoryFor(text string) string {
+	words := make(map[string]struct{})
+	for _, w := range wordPattern.FindAllString(strings.ToLower(text), -1) {
+		words[w] = struct{}{}
+	}
+	for _, k := range categoryKeywords {
+		for _, w := range k.words {
+			if _, ok := words[w]; ok {
+				return k.category
+			}
+		}
+	}
+	return fallbackCategory
+}
+
+// slugify turns a name into a lowercase, dash-separated ID.
+func slugify(name string) string {
+	slug := strings.Join(wordPattern.FindAllString(strings.ToLower(name), -1), "-")
+	if slug == "" {
+		return "badge"
+	}
+	return slug
+}
+
+// uniqueID returns id, or id with a numeric suffix when it is already taken.
+func uniqueID(id string, taken map[string]int) string {
+	taken[id]++
+	if taken[id] == 1 {
+		return id
+	}
+	for n := taken[id]; ; n++ {
+		candidate := fmt.Sprintf("%s-%d", id, n)
+		if taken[candidate] == 0 {
+			taken[candidate]++
+			return candidate
+		}
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
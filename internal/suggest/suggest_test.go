@@ -0,0 +1,134 @@
+package suggest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/catalog"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+)
+
+func TestBuild(t *testing.T) {
+	t.Parallel()
+
+	config := &catalog.Config{Badges: []catalog.Entry{
+		{ID: "license", Pattern: "https://img.shields.io/github/license/{ORG}/{REPO}/.*", Name: "License", Category: "License"},
+	}}
+	crawl := &models.Bundle{
+		Organization: "example",
+		Repositories: []models.RepositoryData{
+			{
+				Repository: "alpha",
+				Badges: []models.Badge{
+					{ImageURL: "https://img.shields.io/github/license/example/alpha", AltText: "License"},
+					{ImageURL: "https://codecov.io/gh/example/alpha/branch/main/graph/badge.svg", AltText: "codecov"},
+					{ImageURL: "https://img.shields.io/badge/status-experimental-orange.svg", AltText: "Status"},
+				},
+			},
+			{
+				Repository: "beta",
+				Badges: []models.Badge{
+					{ImageURL: "https://codecov.io/gh/example/beta/branch/main/graph/badge.svg", AltText: "Coverage"},
+				},
+			},
+		},
+	}
+
+	draft := Build(crawl, config)
+
+	if len(draft.Badges) != 2 {
+		t.Fatalf("Build() = %+v, want the two unknown patterns", draft.Badges)
+	}
+	codecov := draft.Badges[0]
+	if codecov.Pattern != "https://codecov.io/gh/{ORG}/{REPO}/branch/main/graph/badge.svg" || codecov.Repos != 2 {
+		t.Errorf("first suggestion = %s in %d repos, want the Codecov pattern shared by both repos", codecov.Pattern, codecov.Repos)
+	}
+	if codecov.ID != "codecov" || codecov.Name != "Codecov" || codecov.Category != "Coverage" {
+		t.Errorf("Codecov suggestion = %+v", codecov.Entry)
+	}
+	if len(codecov.Examples) != 2 {
+		t.Errorf("Codecov examples = %v, want both image URLs", codecov.Examples)
+	}
+	status := draft.Badges[1]
+	if status.Name != "status" || status.Category != "Project Status" {
+		t.Errorf("static badge suggestion = %+v", status.Entry)
+	}
+
+	// Suggested patterns identify the badges they were drafted from.
+	applied := &catalog.Config{Badges: config.Badges}
+	for _, s := range draft.Badges {
+		applied.Badges = append(applied.Badges, s.Entry)
+	}
+	if again := Build(crawl, applied); len(again.Badges) != 0 {
+		t.Errorf("Build() with the suggestions applied = %+v, want none", again.Badges)
+	}
+}
+
+func TestInfer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern      string
+		altText      string
+		wantName     string
+		wantCategory string
+	}{
+		{"https://img.shields.io/maven-central/v/com.example/lib", "", "Maven Central", "Package"},
+		{"https://img.shields.io/github/actions/workflow/status/{ORG}/{REPO}/ci.yml", "", "GitHub Actions", "Build"},
+		{"https://img.shields.io/badge/License-MIT-yellow.svg", "", "License", "License"},
+		{"https://img.shields.io/badge/code_style-black--white-000000.svg", "", "code style", "Other"},
+		{"https://github.com/{ORG}/{REPO}/actions/workflows/ci.yml/badge.svg?branch=main", "CI", "GitHub Actions", "Build"},
+		{"https://github.com/{ORG}/{REPO}/workflows/CI/badge.svg", "", "GitHub Actions", "Build"},
+		{"https://github.com/{ORG}/{REPO}/raw/main/logo.png", "", "github.com", "Other"},
+		{"https://goreportcard.com/badge/github.com/{ORG}/{REPO}", "", "Go Report Card", "Quality"},
+		{"https://api.securityscorecards.dev/projects/github.com/{ORG}/{REPO}/badge", "", "OpenSSF Scorecard", "Security"},
+		{"https://badges.example.net/{ORG}/{REPO}.svg", "Documentation", "Documentation", "Documentation"},
+		{"https://badges.example.net/{ORG}/{REPO}.svg", "", "badges.example.net", "Other"},
+	}
+	for _, tt := range tests {
+		name, category, _ := infer(tt.pattern, tt.altText)
+		if name != tt.wantName || category != tt.wantCategory {
+			t.Errorf("infer(%q, %q) = %q, %q, want %q, %q", tt.pattern, tt.altText, name, category, tt.wantName, tt.wantCategory)
+		}
+	}
+}
+
+func TestUniqueID(t *testing.T) {
+	t.Parallel()
+
+	taken := map[string]int{"license": 1}
+	for _, want := range []string{"license-2", "license-3"} {
+		if got := uniqueID("license", taken); got != want {
+			t.Errorf("uniqueID() = %q, want %q", got, want)
+		}
+	}
+	if got := uniqueID("codecov", taken); got != "codecov" {
+		t.Errorf("uniqueID() = %q, want codecov", got)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "badges.draft.json")
+	draft := &Draft{Badges: []Suggestion{{
+		Entry: catalog.Entry{ID: "codecov", Pattern: "https://codecov.io/gh/{ORG}/{REPO}/.*", Name: "Codecov", Category: "Coverage"},
+		Repos: 2,
+	}}}
+	if err := Write(path, draft); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// The draft loads as a catalog, ahead of the built-in defaults.
+	config, err := catalog.Load(path)
+	if err != nil {
+		t.Fatalf("catalog.Load(draft) error = %v", err)
+	}
+	if len(config.Badges) == 0 || config.Badges[0].Pattern != draft.Badges[0].Pattern || config.Badges[0].ID != draft.Badges[0].ID {
+		t.Errorf("catalog.Load(draft) first entry = %+v, want %+v", config.Badges[0], draft.Badges[0].Entry)
+	}
+}
@@ -1,13 +1,27 @@
 package main
 
 import (
+	"context"
 	"embed"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/catalog"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/check"
 	"github.com/UnitVectorY-Labs/badgeindexer/internal/crawler"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/dataset"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/diff"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/fetch"
 	"github.com/UnitVectorY-Labs/badgeindexer/internal/generator"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/liveness"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/mirror"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/models"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/policy"
+	"github.com/UnitVectorY-Labs/badgeindexer/internal/suggest"
 )
 
 // appFS embeds templates, assets, and crawler configuration files.
@@ -15,25 +29,68 @@ import (
 //go:embed badge-domains.yaml
 //go:embed templates/*.html
 //go:embed templates/style.css
+//go:embed templates/dashboard.js
+//go:embed templates/htmx.min.js
 var appFS embed.FS
 
 func main() {
 	crawlMode := flag.Bool("crawl", false, "Run the crawler phase")
 	genMode := flag.Bool("generate", false, "Run the generator phase")
+	diffMode := flag.Bool("diff", false, "Compare two crawls given by -from and -to")
+	checkMode := flag.Bool("check", false, "Check a local README against the badge catalog and the -policy file")
+	suggestMode := flag.Bool("suggest", false, "Draft badges.json entries for the Unknown badges of a crawl")
+	validateMode := flag.Bool("validate", false, "Validate the badge catalog, and check its entries against a crawl when one exists")
+	livenessMode := flag.Bool("liveness", false, "Fetch every badge image and link of a crawl and record whether they work")
 	orgName := flag.String("org", "", "GitHub Organization name (required for crawl)")
-	includePrivate := flag.Bool("private", false, "Include private repositories (default: public only)")
+	includePrivate := flag.Bool("private", false, "Include private repositories (default: public only) (crawl)")
 	outputDir := flag.String("output", "data", "Directory for data output (crawl) or input (generate)")
 	htmlDir := flag.String("html", "output", "Directory for HTML output (generate)")
+	fetchStrategy := flag.String("fetch", crawler.StrategyREST, "README fetch strategy for crawl: rest or graphql")
+	refs := flag.String("ref", "", "Comma-separated refs to index in priority order, falling back to the default branch (crawl)")
+	refOverrides := flag.String("ref-overrides", "", "YAML file mapping repositories to the refs to index (crawl)")
+	snapshotDir := flag.String("snapshots", "", "Directory of timestamped crawl snapshots to archive into (crawl) or chart trends from (generate)")
+	extraPaths := flag.String("paths", "", "Comma-separated extra Markdown paths or globs to index besides the README (crawl)")
+	bundlePath := flag.String("bundle", "", "Single-file crawl bundle (.json, .json.gz, .jsonl or .jsonl.gz) to write (crawl) or read (generate) instead of the -output directory")
+	sqlitePath := flag.String("sqlite", "", "SQLite crawl history store to record the crawl in (crawl) or read the latest run from (generate)")
+	policyPath := flag.String("policy", "", "YAML badge policy file to evaluate repositories against (generate)")
+	sarifPath := flag.String("sarif", "", "File to write policy violations and broken badges to as a SARIF log (generate)")
+	diffFrom := flag.String("from", "", "Older crawl to compare: a data directory, bundle file or SQLite store, optionally with #<run ID> (diff)")
+	diffTo := flag.String("to", "", "Newer crawl to compare, in the same forms as -from (diff)")
+	diffFormat := flag.String("format", diff.FormatText, "Diff output format: text, json or markdown (diff)")
+	readmePath := flag.String("readme", "README.md", "Local README to check (check)")
+	repoName := flag.String("repo", os.Getenv("GITHUB_REPOSITORY"), "Repository being checked as owner/name, defaulting to $GITHUB_REPOSITORY (check)")
+	visibility := flag.String("visibility", policy.VisibilityPublic, "Visibility of the checked repository, public or private, for policy scopes (check)")
+	language := flag.String("language", "", "Primary language of the checked repository, for policy scopes (check)")
+	topics := flag.String("topics", "", "Comma-separated topics of the checked repository, for policy scopes (check)")
+	reportPath := flag.String("report", "", "File to write the diff to instead of standard output (diff)")
+	badgesPaths := flag.String("badges", os.Getenv("BADGES_PATH"), "Comma-separated badge catalog files (.json, .yaml or .yml), later files taking precedence; defaults to $BADGES_PATH, then badges.json if it exists")
+	draftPath := flag.String("draft", "badges.draft.json", "File to write the suggested badges.json entries to (suggest)")
+	concurrency := flag.Int("concurrency", fetch.DefaultConcurrency, "Maximum requests in flight (liveness, generate with -mirror)")
+	timeout := flag.Duration("timeout", fetch.DefaultTimeout, "Timeout for each request (liveness, generate with -mirror)")
+	livenessCache := flag.String("liveness-cache", "", "JSON file keeping liveness results between runs (liveness)")
+	livenessMaxAge := flag.Duration("liveness-max-age", 24*time.Hour, "How long cached liveness results are reused (liveness)")
+	mirrorImages := flag.Bool("mirror", false, "Download badge images into the site and serve them from there instead of from the badge services (generate)")
+	mirrorCache := flag.String("mirror-cache", "badge-cache", "Directory keeping mirrored badge images between runs; empty downloads every image again (generate)")
+	mirrorMaxAge := flag.Duration("mirror-max-age", 24*time.Hour, "How long mirrored badge images are used before asking the badge service whether they changed (generate)")
+	csp := flag.Bool("csp", false, "Declare a strict Content-Security-Policy in every page (generate)")
+	basePath := flag.String("base-path", "", "URL or path the site is served under, such as /project for a GitHub Pages project site (generate)")
 
 	flag.Parse()
 
-	if *crawlMode && *genMode {
-		fmt.Println("Error: Cannot run both -crawl and -generate at the same time.")
+	modes := 0
+	for _, mode := range []bool{*crawlMode, *genMode, *diffMode, *checkMode, *suggestMode, *validateMode, *livenessMode} {
+		if mode {
+			modes++
+		}
+	}
+
+	if modes > 1 {
+		fmt.Println("Error: Only one of -crawl, -generate, -diff, -check, -suggest, -validate and -liveness can run at a time.")
 		os.Exit(1)
 	}
 
-	if !*crawlMode && !*genMode {
-		fmt.Println("Usage: badge-indexer [-crawl | -generate] [options]")
+	if modes == 0 {
+		fmt.Println("Usage: badge-indexer [-crawl | -generate | -diff | -check | -suggest | -validate | -liveness] [options]")
 		flag.PrintDefaults()
 		os.Exit(1)
 	}
@@ -53,16 +110,333 @@ func main() {
 			fmt.Printf("Failed to load badge domains: %v\n", err)
 			os.Exit(1)
 		}
-		if err := crawler.Run(*orgName, *outputDir, token, *includePrivate, badgeDomains); err != nil {
+		refSelector := crawler.RefSelector{Default: crawler.ParseRefList(*refs)}
+		if *refOverrides != "" {
+			refSelector.Overrides, err = crawler.LoadRefOverrides(*refOverrides)
+			if err != nil {
+				fmt.Printf("Failed to load ref overrides: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		opts := crawler.Options{
+			OrgName:        *orgName,
+			OutputDir:      *outputDir,
+			Token:          token,
+			IncludePrivate: *includePrivate,
+			BadgeDomains:   badgeDomains,
+			Strategy:       *fetchStrategy,
+			Refs:           refSelector,
+			ExtraPaths:     crawler.ParsePathList(*extraPaths),
+			BundlePath:     *bundlePath,
+			SQLitePath:     *sqlitePath,
+			SnapshotDir:    *snapshotDir,
+		}
+		if err := crawler.Run(opts); err != nil {
 			fmt.Printf("Crawl failed: %v\n", err)
 			os.Exit(1)
 		}
 	}
 
 	if *genMode {
-		if err := generator.Run(*outputDir, *htmlDir, appFS); err != nil {
+		var badgePolicy *policy.Policy
+		if *policyPath != "" {
+			var err error
+			badgePolicy, err = policy.Load(*policyPath)
+			if err != nil {
+				fmt.Printf("Failed to load policy: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		badgeCatalog, err := catalog.Load(catalogPaths(*badgesPaths)...)
+		if err != nil {
+			fmt.Printf("Failed to load badge catalog: %v\n", err)
+			os.Exit(1)
+		}
+		opts := generator.Options{
+			Source: dataset.Source{
+				Dir:    *outputDir,
+				Bundle: *bundlePath,
+				SQLite: *sqlitePath,
+			},
+			Catalog:     badgeCatalog,
+			SnapshotDir: *snapshotDir,
+			Policy:      badgePolicy,
+			SARIFPath:   *sarifPath,
+			OutputDir:   *htmlDir,
+			CSP:         *csp,
+			BasePath:    *basePath,
+			TemplateFS:  appFS,
+		}
+		if *mirrorImages {
+			opts.Mirror = &mirror.Options{
+				CacheDir:    *mirrorCache,
+				MaxAge:      *mirrorMaxAge,
+				Concurrency: *concurrency,
+				Timeout:     *timeout,
+			}
+		}
+		if err := generator.Run(opts); err != nil {
 			fmt.Printf("Generation failed: %v\n", err)
 			os.Exit(1)
 		}
 	}
+
+	if *checkMode {
+		if *visibility != policy.VisibilityPublic && *visibility != policy.VisibilityPrivate {
+			fmt.Printf("Error: -visibility must be %s or %s.\n", policy.VisibilityPublic, policy.VisibilityPrivate)
+			os.Exit(1)
+		}
+		badgeDomains, err := crawler.LoadBadgeDomains(appFS, "badge-domains.yaml")
+		if err != nil {
+			fmt.Printf("Failed to load badge domains: %v\n", err)
+			os.Exit(1)
+		}
+		var badgePolicy *policy.Policy
+		if *policyPath != "" {
+			badgePolicy, err = policy.Load(*policyPath)
+			if err != nil {
+				fmt.Printf("Failed to load policy: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		badgeCatalog, err := catalog.Load(catalogPaths(*badgesPaths)...)
+		if err != nil {
+			fmt.Printf("Failed to load badge catalog: %v\n", err)
+			os.Exit(1)
+		}
+
+		// The repository defaults to the README's directory in the -org owner
+		owner, name, found := strings.Cut(*repoName, "/")
+		if !found {
+			owner, name = *orgName, *repoName
+		}
+		if name == "" {
+			if dir, err := filepath.Abs(filepath.Dir(*readmePath)); err == nil {
+				name = filepath.Base(dir)
+			}
+		}
+
+		result, err := check.Run(check.Options{
+			ReadmePath:   *readmePath,
+			Organization: owner,
+			Repository:   name,
+			Private:      *visibility == policy.VisibilityPrivate,
+			Language:     *language,
+			Topics:       strings.FieldsFunc(*topics, func(r rune) bool { return r == ',' || r == ' ' }),
+			BadgeDomains: badgeDomains,
+			Catalog:      badgeCatalog,
+			Policy:       badgePolicy,
+		})
+		if err != nil {
+			fmt.Printf("Check failed: %v\n", err)
+			os.Exit(1)
+		}
+		check.Print(os.Stdout, result)
+		if result.Violations() > 0 {
+			os.Exit(1)
+		}
+	}
+
+	if *suggestMode {
+		src := dataset.Source{Dir: *outputDir, Bundle: *bundlePath, SQLite: *sqlitePath}
+		if err := runSuggest(src, catalogPaths(*badgesPaths), *draftPath); err != nil {
+			fmt.Printf("Suggest failed: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *validateMode {
+		src := dataset.Source{Dir: *outputDir, Bundle: *bundlePath, SQLite: *sqlitePath}
+		errorCount, err := runValidate(catalogPaths(*badgesPaths), src)
+		if err != nil {
+			fmt.Printf("Validation failed: %v\n", err)
+			os.Exit(1)
+		}
+		if errorCount > 0 {
+			os.Exit(1)
+		}
+	}
+
+	if *livenessMode {
+		src := dataset.Source{Dir: *outputDir, Bundle: *bundlePath, SQLite: *sqlitePath}
+		opts := liveness.Options{Concurrency: *concurrency, Timeout: *timeout, MaxAge: *livenessMaxAge}
+		if err := runLiveness(src, opts, *livenessCache); err != nil {
+			fmt.Printf("Liveness check failed: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *diffMode {
+		if *diffFrom == "" || *diffTo == "" {
+			fmt.Println("Error: -from and -to are required for diff mode.")
+			os.Exit(1)
+		}
+		if err := runDiff(*diffFrom, *diffTo, catalogPaths(*badgesPaths), *diffFormat, *reportPath); err != nil {
+			fmt.Printf("Diff failed: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// runDiff compares two crawls and writes the report to reportPath, or to
+// standard output when it is empty.
+func runDiff(fromSpec, toSpec string, catalogFiles []string, format, reportPath string) error {
+	load := func(spec string) (*models.Bundle, string, error) {
+		src, err := dataset.ParseSource(spec)
+		if err != nil {
+			return nil, "", err
+		}
+		bundle, err := dataset.Load(src)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to load %s: %w", src, err)
+		}
+		return bundle, src.String(), nil
+	}
+
+	from, fromName, err := load(fromSpec)
+	if err != nil {
+		return err
+	}
+	to, toName, err := load(toSpec)
+	if err != nil {
+		return err
+	}
+
+	config, err := catalog.Load(catalogFiles...)
+	if err != nil {
+		return err
+	}
+
+	report := diff.Compare(from, to, config)
+	report.From.Source = fromName
+	report.To.Source = toName
+
+	out := os.Stdout
+	if reportPath != "" {
+		file, err := os.Create(reportPath)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", reportPath, err)
+		}
+		defer file.Close()
+		out = file
+	}
+	return diff.Write(out, report, format)
+}
+
+// runLiveness checks the badge images and links of a crawl, records the
+// results in it and lists the broken badges.
+func runLiveness(src dataset.Source, opts liveness.Options, cachePath string) error {
+	crawl, err := dataset.Load(src)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", src, err)
+	}
+	if cachePath != "" {
+		if opts.Cache, err = liveness.LoadCache(cachePath); err != nil {
+			return err
+		}
+	}
+
+	summary := liveness.Check(context.Background(), crawl, opts)
+	if err := dataset.Save(src, crawl); err != nil {
+		return err
+	}
+	if opts.Cache != nil {
+		if err := opts.Cache.Save(cachePath, opts.MaxAge); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Checked %d URLs (%d fetched, %d cached): %d broken badge images, %d broken badge links\n",
+		summary.URLs, summary.Fetched, summary.Cached, summary.BrokenImages, summary.BrokenTargets)
+	printBroken := func(owner string, badges []models.Badge) {
+		for _, b := range badges {
+			if problem := liveness.ImageProblem(b.ImageStatus); problem != "" {
+				fmt.Printf("  %s: image %s: %s\n", owner, b.ImageURL, problem)
+			}
+			if problem := liveness.TargetProblem(b.TargetStatus); problem != "" {
+				fmt.Printf("  %s: link %s: %s\n", owner, b.TargetURL, problem)
+			}
+		}
+	}
+	for _, repo := range crawl.Repositories {
+		printBroken(repo.Repository, repo.Badges)
+	}
+	if crawl.Profile != nil {
+		printBroken("organization profile", crawl.Profile.Badges)
+	}
+	fmt.Printf("Recorded the results in %s\n", src)
+	return nil
+}
+
+// runSuggest drafts catalog entries for the crawl's Unknown badges.
+func runSuggest(src dataset.Source, catalogFiles []string, draftPath string) error {
+	crawl, err := dataset.Load(src)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", src, err)
+	}
+
+	config, err := catalog.Load(catalogFiles...)
+	if err != nil {
+		return err
+	}
+
+	draft := suggest.Build(crawl, config)
+	if err := suggest.Write(draftPath, draft); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %d suggested badges.json entries to %s\n", len(draft.Badges), draftPath)
+	return nil
+}
+
+// runValidate reports the problems in a catalog, including entries that
+// identify no badge of the crawl when it exists, and returns the number of
+// errors.
+func runValidate(catalogFiles []string, src dataset.Source) (int, error) {
+	config, err := catalog.Load(catalogFiles...)
+	if err != nil {
+		return 0, err
+	}
+	catalogName := catalog.DefaultPath
+	if len(catalogFiles) > 0 {
+		catalogName = strings.Join(catalogFiles, ", ")
+	}
+
+	// Without a crawl only the catalog itself can be checked
+	var patterns []string
+	if _, statErr := os.Stat(src.Dir); src.Bundle != "" || src.SQLite != "" || statErr == nil {
+		crawl, err := dataset.Load(src)
+		if err != nil {
+			return 0, fmt.Errorf("failed to load %s: %w", src, err)
+		}
+		orgName := dataset.OrganizationName(crawl)
+		patterns = []string{}
+		for _, repo := range crawl.Repositories {
+			for _, b := range repo.Badges {
+				patterns = append(patterns, catalog.Canonicalize(b.ImageURL, orgName, repo.Repository))
+			}
+		}
+		fmt.Printf("Checking %s against %d badges from %s\n", catalogName, len(patterns), src)
+	} else {
+		fmt.Printf("Checking %s; no crawl found at %s, so entries are not checked against badges\n", catalogName, src)
+	}
+
+	problems := catalog.Validate(config, patterns)
+	for _, problem := range problems {
+		fmt.Printf("  %s\n", problem)
+	}
+	errorCount := catalog.Errors(problems)
+	fmt.Printf("%d errors, %d warnings\n", errorCount, len(problems)-errorCount)
+	return errorCount, nil
+}
+
+// catalogPaths splits the comma-separated -badges value into file paths.
+func catalogPaths(raw string) []string {
+	var paths []string
+	for path := range strings.SplitSeq(raw, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
 }
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestRunDiffJSONWithMigration checks that notices about migrated records
+// stay out of a JSON diff written to standard output.
+func TestRunDiffJSONWithMigration(t *testing.T) {
+	// Records without schema_version are migrated while loading
+	dir := t.TempDir()
+	legacy := `{"repository":"alpha","repository_url":"https://github.com/Org/alpha","default_branch":"main","readme_found":true,"badges":[]}`
+	if err := os.WriteFile(filepath.Join(dir, "alpha.json"), []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	read, write, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = write
+	t.Cleanup(func() { os.Stdout = stdout })
+	output := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(read)
+		output <- data
+	}()
+
+	err = runDiff(dir, dir, nil, "json", "")
+	os.Stdout = stdout
+	write.Close()
+	data := <-output
+	if err != nil {
+		t.Fatalf("runDiff() error = %v", err)
+	}
+
+	var report map[string]any
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Errorf("runDiff() wrote invalid JSON to standard output: %v\n%s", err, data)
+	}
+}
@@ -0,0 +1,110 @@
+// Repository filters of the dashboard. The handlers are delegated from the
+// document, so they keep working when htmx swaps the dashboard in, and the
+// filter state lives in the badge elements' classes, so a freshly swapped
+// dashboard starts unfiltered.
+(function () {
+    function badgeFilters(className) {
+        return Array.from(document.querySelectorAll('.badge-filter.' + className)).map(function (el) {
+            return el.getAttribute('data-badge-id');
+        });
+    }
+
+    function toggleBadgeFilter(el) {
+        if (el.classList.contains('selected')) {
+            el.classList.remove('selected');
+            // When deselecting, also remove the inversion
+            el.classList.remove('inverted');
+        } else {
+            el.classList.add('selected');
+        }
+        applyFilters();
+    }
+
+    function toggleBadgeInvert(el) {
+        var badgeFilter = el.closest('.badge-filter');
+        // Inverting a badge selects it
+        badgeFilter.classList.add('selected');
+        badgeFilter.classList.toggle('inverted');
+        applyFilters();
+    }
+
+    function applyFilters() {
+        var searchInput = document.getElementById('searchInput');
+        if (!searchInput) {
+            return;
+        }
+        var searchTerm = searchInput.value.toLowerCase();
+        var stateFilter = document.getElementById('stateFilter');
+        var state = stateFilter ? stateFilter.value : '';
+        var selectedBadges = badgeFilters('selected');
+        var invertedBadges = badgeFilters('inverted');
+        var rows = document.querySelectorAll('.repo-row');
+
+        rows.forEach(function (row) {
+            var name = row.getAttribute('data-name').toLowerCase();
+            var badges = row.getAttribute('data-badges').split(' ').filter(Boolean);
+            var states = row.getAttribute('data-states').split(' ').filter(Boolean);
+
+            var matchesSearch = name.includes(searchTerm);
+
+            // If inverted, we want repos that DON'T have the badge
+            // If not inverted, we want repos that DO have the badge
+            var matchesBadges = selectedBadges.every(function (bid) {
+                var hasBadge = badges.includes(bid);
+                return invertedBadges.includes(bid) ? !hasBadge : hasBadge;
+            });
+
+            // The state applies to the selected badges the repo must have, or
+            // to any of its badges when none is selected
+            var matchesState = true;
+            if (state) {
+                var required = selectedBadges.filter(function (bid) {
+                    return !invertedBadges.includes(bid);
+                });
+                if (required.length > 0) {
+                    matchesState = required.every(function (bid) {
+                        return states.includes(bid + ':' + state);
+                    });
+                } else {
+                    matchesState = states.some(function (s) {
+                        return s.endsWith(':' + state);
+                    });
+                }
+            }
+
+            if (matchesSearch && matchesBadges && matchesState) {
+                row.classList.remove('hidden');
+            } else {
+                row.classList.add('hidden');
+            }
+        });
+    }
+
+    document.addEventListener('click', function (event) {
+        var invert = event.target.closest('.badge-filter-invert');
+        if (invert) {
+            toggleBadgeInvert(invert);
+            return;
+        }
+        // The badge image links to the badge page instead of filtering
+        if (event.target.closest('.badge-filter a')) {
+            return;
+        }
+        var badgeFilter = event.target.closest('.badge-filter');
+        if (badgeFilter) {
+            toggleBadgeFilter(badgeFilter);
+        }
+    });
+
+    document.addEventListener('input', function (event) {
+        if (event.target.id === 'searchInput') {
+            applyFilters();
+        }
+    });
+
+    document.addEventListener('change', function (event) {
+        if (event.target.id === 'stateFilter') {
+            applyFilters();
+        }
+    });
+})();